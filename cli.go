@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+	"github.com/microlib/simple"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+var logLevels = []string{"trace", "debug", "info", "warn", "error"}
+
+// config holds the flags shared by every command that reads a flow
+type config struct {
+	flow      string
+	templates string
+	logLevel  string
+	schema    string
+	schemas   string
+	tracking  string
+	inject    string
+	linkMode  string
+	csp       string
+	sri       bool
+	logger    *simple.Logger
+}
+
+// newFlagSet returns the flag set of a command, its usage line shows the positional args
+func newFlagSet(name string, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: url-linker %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// register adds the shared flags to fs, the link and render options keep their defaults
+// unless registerOptions adds their flags too
+func (c *config) register(fs *flag.FlagSet) {
+	c.linkMode, c.csp, c.inject = linker.LinkPagetype, linker.CSPOff, linker.InjectAuto
+	fs.StringVar(&c.flow, "flow", "", "designer flow json (default <templates>/flow.json)")
+	fs.StringVar(&c.templates, "templates", ".", "template root holding one folder per page component")
+	fs.StringVar(&c.logLevel, "log-level", "info", "log level ("+strings.Join(logLevels, "|")+")")
+	fs.StringVar(&c.schema, "schema", "short", "page schema used when a component does not set one")
+	fs.StringVar(&c.schemas, "schemas", "", "directory of json page schema definitions to load")
+}
+
+// registerOptions adds the flags of the link and render options to fs, for the commands
+// that link the flow
+func (c *config) registerOptions(fs *flag.FlagSet) {
+	fs.StringVar(&c.tracking, "tracking", "", "json tracking profile replacing the one in the flow variables")
+	fs.StringVar(&c.linkMode, "link-mode", linker.LinkPagetype, "how pages emit their links when neither the page nor the flow chooses ("+strings.Join(linker.LinkModes, "|")+")")
+	fs.StringVar(&c.csp, "csp", linker.CSPOff, "emit a Content-Security-Policy per page ("+linker.CSPOff+"|"+linker.CSPMeta+"|"+linker.CSPHeaders+")")
+	fs.BoolVar(&c.sri, "sri", false, "add integrity hashes to the script and stylesheet tags of local assets")
+	fs.StringVar(&c.inject, "inject-script", linker.InjectAuto, "add the injectParams helper to the pages that need it ("+linker.InjectAuto+"|"+linker.InjectOff+")")
+}
+
+// modeFlag is a file mode flag given in octal
+type modeFlag os.FileMode
+
+func (m *modeFlag) String() string {
+	return fmt.Sprintf("%04o", uint32(*m))
+}
+
+func (m *modeFlag) Set(value string) error {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || os.FileMode(mode)&^os.ModePerm != 0 {
+		return fmt.Errorf("invalid file mode %q", value)
+	}
+	*m = modeFlag(mode)
+	return nil
+}
+
+// parse parses the command line, ok is false when the command should exit with code
+func parse(fs *flag.FlagSet, args []string) (ok bool, code int) {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return false, exitOK
+	}
+	if err != nil {
+		return false, exitUsage
+	}
+	return true, exitOK
+}
+
+// usageError reports a bad command line the way the flag package does
+func usageError(fs *flag.FlagSet, format string, args ...interface{}) int {
+	fmt.Fprintf(fs.Output(), format+"\n", args...)
+	fs.Usage()
+	return exitUsage
+}
+
+// setup checks the shared flags, taking the flow from the first positional arg when --flow
+// is not set, and creates the logger
+func (c *config) setup(fs *flag.FlagSet) error {
+	valid := false
+	for _, level := range logLevels {
+		valid = valid || level == c.logLevel
+	}
+	if !valid {
+		return fmt.Errorf("invalid log level %q", c.logLevel)
+	}
+	if c.inject != linker.InjectAuto && c.inject != linker.InjectOff {
+		return fmt.Errorf("invalid inject script mode %q", c.inject)
+	}
+	switch c.csp {
+	case linker.CSPOff, linker.CSPMeta, linker.CSPHeaders:
+	default:
+		return fmt.Errorf("invalid csp mode %q", c.csp)
+	}
+	valid = false
+	for _, mode := range linker.LinkModes {
+		valid = valid || mode == c.linkMode
+	}
+	if !valid {
+		return fmt.Errorf("invalid link mode %q", c.linkMode)
+	}
+	switch {
+	case fs.NArg() > 1 || (fs.NArg() == 1 && c.flow != ""):
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	case fs.NArg() == 1:
+		c.flow = fs.Arg(0)
+	}
+	if c.flow == "" {
+		c.flow = filepath.Join(c.templates, "flow.json")
+	}
+	c.logger = &simple.Logger{Level: c.logLevel}
+	return nil
+}
+
+// load reads the schema definitions, the flow and the tracking profile
+func (c *config) load() (*linker.Flow, linker.Options, error) {
+	opts, err := c.options()
+	if err != nil {
+		return nil, opts, err
+	}
+	flow, err := readFlow(c.flow)
+	if err != nil {
+		return nil, opts, err
+	}
+	return flow, opts, nil
+}
+
+// options reads the schema definitions and the tracking profile
+func (c *config) options() (linker.Options, error) {
+	opts := linker.Options{Dir: c.templates, Schema: c.schema, Inject: c.inject, LinkMode: c.linkMode, CSP: c.csp, SRI: c.sri}
+	if c.schemas != "" {
+		if err := linker.LoadSchemas(c.schemas); err != nil {
+			return opts, fmt.Errorf("loading schema definitions %v", err)
+		}
+	}
+	var err error
+	opts.Tracking, err = readTracking(c.tracking)
+	return opts, err
+}
+
+// readFlow opens and parses the designer flow json
+func readFlow(name string) (*linker.Flow, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading designer flow %v", err)
+	}
+	defer file.Close()
+	return linker.Parse(file)
+}
+
+// readTracking parses the tracking profile file, there is none when name is empty
+func readTracking(name string) (*linker.TrackingProfile, error) {
+	if name == "" {
+		return nil, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading tracking profile %v", err)
+	}
+	defer file.Close()
+	return linker.ParseTracking(file)
+}
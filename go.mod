@@ -0,0 +1,5 @@
+module github.com/luigizuccarelli/golang-url-linker
+
+go 1.21
+
+require github.com/microlib/simple v1.0.2
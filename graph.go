@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+// graph writes the linked funnel to stdout (or -o) as a Graphviz or Mermaid diagram, it returns the exit code
+func graph(args []string) int {
+	var c config
+	fs := newFlagSet("graph", "[flow.json]")
+	c.register(fs)
+	c.registerOptions(fs)
+	format := fs.String("format", "dot", "diagram format (dot|mermaid)")
+	tabs := fs.Bool("tabs", false, "group the pages of each designer tab")
+	output := fs.String("o", "", "file to write the diagram to (default stdout)")
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	logger := c.logger
+
+	write := linker.WriteDOT
+	switch *format {
+	case "dot":
+	case "mermaid":
+		write = linker.WriteMermaid
+	default:
+		return usageError(fs, "unknown graph format %q", *format)
+	}
+
+	flow, opts, err := c.load()
+	if err != nil {
+		logger.Error(err.Error())
+		return exitInput
+	}
+	result, err := linker.Link(flow, opts)
+	if err != nil {
+		logger.Error(err.Error())
+		return exitInput
+	}
+	for _, err := range result.Errors() {
+		logger.Warn(err.Error())
+	}
+	w := os.Stdout
+	if *output != "" {
+		if w, err = os.Create(*output); err != nil {
+			logger.Error(fmt.Sprintf("Writing graph %v", err))
+			return exitIO
+		}
+		defer w.Close()
+	}
+	if err = write(w, flow, result, linker.GraphOptions{Tabs: *tabs}); err != nil {
+		logger.Error(fmt.Sprintf("Writing graph %v", err))
+		return exitIO
+	}
+	return exitOK
+}
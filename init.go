@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+const starterTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .title }}</title>
+</head>
+<body>
+<h1>{{ .headline }}</h1>
+<p>{{ .subheadline }}</p>
+%s
+</body>
+</html>
+`
+
+// initFunnel creates a starter flow with an origin page linking to a thank you page, it
+// returns the exit code
+func initFunnel(args []string) int {
+	var c config
+	fs := newFlagSet("init", "")
+	c.register(fs)
+	force := fs.Bool("force", false, "overwrite existing files")
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	logger := c.logger
+
+	if c.schemas != "" {
+		if err := linker.LoadSchemas(c.schemas); err != nil {
+			logger.Error(fmt.Sprintf("Loading schema definitions %v", err))
+			return exitInput
+		}
+	}
+	def, ok := linker.LookupSchema(c.schema)
+	if !ok {
+		return usageError(fs, "unknown schema %q", c.schema)
+	}
+	cta := ""
+	if slots := def.Ports["0"]; len(slots) > 0 {
+		cta = fmt.Sprintf(`<a href="{{ .%s }}">{{ .cta }}</a>`, slots[0])
+	}
+
+	landing := linker.FileDetails{Output: "index.html", Content: "content.json", Pagename: "landing", Pagetype: "origin", Schema: c.schema}
+	thankyou := linker.FileDetails{Output: "index.html", Content: "content.json", Pagename: "thankyou", Pagetype: "thankyou", Schema: c.schema}
+	flow := linker.Flow{Created: time.Now().UTC()}
+	flow.Tabs = []linker.Tab{{Name: "Funnel", Linker: "funnel", ID: "tab1"}}
+	for i, v := range [][2]string{
+		{"utm_campaign", "campaign"},
+		{"utm_content", "content"},
+		{"utm_medium", "medium"},
+		{"affiliate", "affiliate"},
+		{"base_url", "https://example.com/"},
+	} {
+		flow.Components = append(flow.Components, linker.Component{ID: fmt.Sprintf("var%d", i+1), Component: "comment", Tab: "tab1", Reference: v[0], Name: v[1], X: 40, Y: 40 + i*60})
+	}
+	flow.Components = append(flow.Components,
+		linker.Component{ID: "page1", Component: "page", Tab: "tab1", Name: "landing", Reference: "Landing", X: 300, Y: 100,
+			Connections: linker.Connection{"0": {{Index: "0", ID: "page2"}}},
+			Options:     linker.OptionDetails{Outputs: 1, Template: descriptor(landing)}},
+		linker.Component{ID: "page2", Component: "page", Tab: "tab1", Name: "thankyou", Reference: "Thankyou", X: 600, Y: 100,
+			Options: linker.OptionDetails{Template: descriptor(thankyou)}},
+	)
+	data, _ := json.MarshalIndent(flow, "", "  ")
+
+	files := []struct {
+		name string
+		data string
+	}{
+		{c.flow, string(data) + "\n"},
+		{filepath.Join(c.templates, "landing", "template.html"), fmt.Sprintf(starterTemplate, cta)},
+		{filepath.Join(c.templates, "landing", "content.json"), `{"title": "Welcome", "headline": "Headline", "subheadline": "Sub headline", "cta": "Continue"}` + "\n"},
+		{filepath.Join(c.templates, "thankyou", "template.html"), fmt.Sprintf(starterTemplate, "")},
+		{filepath.Join(c.templates, "thankyou", "content.json"), `{"title": "Thank you", "headline": "Thank you", "subheadline": ""}` + "\n"},
+	}
+	if !*force {
+		for _, f := range files {
+			if _, err := os.Stat(f.name); err == nil {
+				logger.Error(fmt.Sprintf("%s already exists, use --force to overwrite", f.name))
+				return exitFailure
+			}
+		}
+	}
+	for _, f := range files {
+		err := os.MkdirAll(filepath.Dir(f.name), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(f.name, []byte(f.data), 0644)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Writing file %v", err))
+			return exitIO
+		}
+		logger.Info(fmt.Sprintf("Created %s", f.name))
+	}
+	return exitOK
+}
+
+func descriptor(f linker.FileDetails) string {
+	data, _ := json.Marshal(f)
+	return string(data)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a url-linker subcommand, run returns the process exit code
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"render", "link the flow and render every page", render},
+		{"watch", "render, then rebuild the affected pages whenever a source changes", watch},
+		{"serve", "render flows posted to an http service", serve},
+		{"preview", "serve the rendered funnel from memory for local clicking", preview},
+		{"validate", "report structural problems in the funnel graph", validate},
+		{"graph", "export the funnel as a Graphviz or Mermaid diagram", graph},
+		{"init", "create a starter flow and page folders", initFunnel},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+	name := os.Args[1]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		os.Exit(0)
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "url-linker: unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(exitUsage)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: url-linker <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'url-linker <command> --help' for the flags of a command.")
+}
@@ -0,0 +1,101 @@
+package linker
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TemplateFile is the page template every page folder holds
+const TemplateFile = "template.html"
+
+// Sources are the files of the page folder the linker reads, relative to the folder
+func (p *Page) Sources() []string {
+	return []string{TemplateFile, p.Files.Content}
+}
+
+// Outputs are the files the linker writes for the page, relative to the folder
+func (p *Page) Outputs() []string {
+	return []string{p.Files.Output, p.Files.Output + BackupSuffix, p.Files.Output + HeadersSuffix}
+}
+
+// IsAsset reports whether rel, a slash separated path in the page folder, is a static asset
+// of the page: not one of its sources, not a file the linker writes and not hidden
+func (p *Page) IsAsset(rel string) bool {
+	if rel == "" {
+		return false
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	for _, name := range append(p.Sources(), p.Outputs()...) {
+		if rel == name {
+			return false
+		}
+	}
+	return true
+}
+
+// CopyAssets mirrors the static assets of the page folder into the page folder under out,
+// files that already match in size and modification time are left alone
+func CopyAssets(page *Page, dir string, out string) error {
+	src := filepath.Join(dir, page.Component.Name)
+	dst := filepath.Join(out, page.Component.Name)
+	return walkAssets(page, src, func(name string, rel string, info os.FileInfo) error {
+		target := filepath.Join(dst, rel)
+		if existing, err := os.Stat(target); err == nil && existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+			return nil
+		}
+		return copyFile(name, target, info)
+	})
+}
+
+// walkAssets calls fn for every static asset of the page folder src, everything but its
+// sources, the files the linker writes and hidden files, in lexical order
+func walkAssets(page *Page, src string, fn func(name string, rel string, info os.FileInfo) error) error {
+	return filepath.Walk(src, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !page.IsAsset(filepath.ToSlash(rel)) {
+			return nil
+		}
+		return fn(name, rel, info)
+	})
+}
+
+func copyFile(src string, dst string, info os.FileInfo) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,154 @@
+package linker
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// how the Content-Security-Policy of a page is emitted
+const (
+	CSPOff = "off"
+	// CSPMeta puts the policy in a <meta http-equiv> tag at the start of the head
+	CSPMeta = "meta"
+	// CSPHeaders writes it to a sidecar <output>.headers file for the web server
+	CSPHeaders = "headers"
+)
+
+// HeadersSuffix is appended to the output of a page to name its sidecar headers file
+const HeadersSuffix = ".headers"
+
+// basePolicy is the policy of every page before the inline script hashes and the sources
+// its descriptor allows. Everything not named falls back to default-src
+var basePolicy = map[string][]string{
+	"default-src": {"'self'"},
+	"script-src":  {"'self'"},
+	"object-src":  {"'none'"},
+	"base-uri":    {"'self'"},
+}
+
+// Policy returns the Content-Security-Policy of the rendered page: the base policy, the
+// hashes of the inline scripts the linker injected and the sources the page descriptor
+// allows per directive ({"csp": {"frame-src": ["https://www.youtube.com"]}})
+func Policy(page *Page, data []byte) string {
+	directives := map[string][]string{}
+	for name, sources := range basePolicy {
+		directives[name] = append([]string(nil), sources...)
+	}
+	if strings.Contains(string(data), injectScriptTag) {
+		directives["script-src"] = append(directives["script-src"], scriptHash(InjectScript))
+	}
+	for name, sources := range page.Files.CSP {
+		directives[name] = append(directives[name], sources...)
+	}
+
+	var names []string
+	for name := range directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var policy []string
+	for _, name := range names {
+		policy = append(policy, name+" "+strings.Join(unique(directives[name]), " "))
+	}
+	return strings.Join(policy, "; ")
+}
+
+// scriptHash is the CSP source of an inline script with the body the linker injects, the
+// script element holds the body between two newlines
+func scriptHash(body string) string {
+	sum := sha256.Sum256([]byte("\n" + body + "\n"))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+var (
+	headStart = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	// attrEscaper escapes a double quoted attribute value, the quotes of the sources stay readable
+	attrEscaper = strings.NewReplacer("&", "&amp;", `"`, "&#34;", "<", "&lt;", ">", "&gt;")
+)
+
+// addPolicyMeta puts the policy first in the head so it covers every script after it
+func addPolicyMeta(data []byte, policy string) []byte {
+	meta := `<meta http-equiv="Content-Security-Policy" content="` + attrEscaper.Replace(policy) + `">`
+	if loc := headStart.FindIndex(data); loc != nil {
+		out := make([]byte, 0, len(data)+len(meta))
+		out = append(out, data[:loc[1]]...)
+		out = append(out, meta...)
+		return append(out, data[loc[1]:]...)
+	}
+	return append([]byte(meta+"\n"), data...)
+}
+
+var (
+	assetTag      = regexp.MustCompile(`(?is)<(?:script|link)\s[^>]*>`)
+	srcAttr       = regexp.MustCompile(`(?is)\s(?:src|href)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	integrityAttr = regexp.MustCompile(`(?is)\sintegrity\s*=`)
+	scriptOrStyle = regexp.MustCompile(`(?is)^<script|\srel\s*=\s*["']?(?:stylesheet|preload|modulepreload)\b`)
+)
+
+// addIntegrity adds the sha384 integrity of the local file to the script and stylesheet
+// tags that point at one in the page folder dir, urls with a scheme or host and tags that
+// already carry an integrity are left alone
+func addIntegrity(data []byte, dir string) []byte {
+	return assetTag.ReplaceAllFunc(data, func(tag []byte) []byte {
+		m := srcAttr.FindSubmatch(tag)
+		if m == nil || integrityAttr.Match(tag) || !scriptOrStyle.Match(tag) {
+			return tag
+		}
+		name, ok := localAsset(html.UnescapeString(string(m[1])+string(m[2])), dir)
+		if !ok {
+			return tag
+		}
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return tag
+		}
+		sum := sha512.Sum384(content)
+		attr := ` integrity="sha384-` + base64.StdEncoding.EncodeToString(sum[:]) + `"`
+		// the attribute goes before the closing > (or the space and / of />)
+		end := len(tag) - 1
+		if end > 0 && tag[end-1] == '/' {
+			end--
+			for end > 0 && strings.ContainsRune(" \t\r\n", rune(tag[end-1])) {
+				end--
+			}
+		}
+		out := make([]byte, 0, len(tag)+len(attr))
+		out = append(out, tag[:end]...)
+		out = append(out, attr...)
+		return append(out, tag[end:]...)
+	})
+}
+
+// localAsset resolves a relative url to a file of the page folder, it does not leave the folder
+func localAsset(ref string, dir string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	clean := path.Clean(u.Path)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean)), true
+}
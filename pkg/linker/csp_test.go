@@ -0,0 +1,130 @@
+package linker
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAddIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "js"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"app.js": "console.log(1)\n", "style.css": "body{}\n", filepath.Join("js", "lib.js"): "var x\n"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a file next to the page folder that a ../ url would reach
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(dir), "outside.js"), []byte("evil\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sri := func(content string) string {
+		sum := sha512.Sum384([]byte(content))
+		return `integrity="sha384-` + base64.StdEncoding.EncodeToString(sum[:]) + `"`
+	}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"local script", `<script src="app.js"></script>`,
+			`<script src="app.js" ` + sri("console.log(1)\n") + `></script>`},
+		{"single quoted subfolder script", `<script src='js/lib.js'></script>`,
+			`<script src='js/lib.js' ` + sri("var x\n") + `></script>`},
+		{"stylesheet", `<link rel="stylesheet" href="style.css">`,
+			`<link rel="stylesheet" href="style.css" ` + sri("body{}\n") + `>`},
+		{"self-closing stylesheet", `<link rel="stylesheet" href="style.css" />`,
+			`<link rel="stylesheet" href="style.css" ` + sri("body{}\n") + ` />`},
+		{"self-closing stylesheet without space", `<link rel="stylesheet" href="style.css"/>`,
+			`<link rel="stylesheet" href="style.css" ` + sri("body{}\n") + `/>`},
+		{"remote url", `<script src="https://cdn.example.com/app.js"></script>`,
+			`<script src="https://cdn.example.com/app.js"></script>`},
+		{"protocol relative url", `<script src="//cdn.example.com/app.js"></script>`,
+			`<script src="//cdn.example.com/app.js"></script>`},
+		{"root relative url", `<script src="/app.js"></script>`,
+			`<script src="/app.js"></script>`},
+		{"escapes the page folder", `<script src="../outside.js"></script>`,
+			`<script src="../outside.js"></script>`},
+		{"escapes after a subfolder", `<script src="js/../../outside.js"></script>`,
+			`<script src="js/../../outside.js"></script>`},
+		{"existing integrity", `<script src="app.js" integrity="sha384-abc"></script>`,
+			`<script src="app.js" integrity="sha384-abc"></script>`},
+		{"missing file", `<script src="gone.js"></script>`,
+			`<script src="gone.js"></script>`},
+		{"link that is not a stylesheet", `<link rel="icon" href="style.css">`,
+			`<link rel="icon" href="style.css">`},
+		{"inline script", `<script>var y</script>`,
+			`<script>var y</script>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(addIntegrity([]byte(tt.in), dir)); got != tt.want {
+				t.Errorf("addIntegrity(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPolicyMeta(t *testing.T) {
+	policy := `default-src 'self'; img-src https://example.com/?a=1&b="2"`
+	meta := `<meta http-equiv="Content-Security-Policy" content="default-src 'self'; img-src https://example.com/?a=1&amp;b=&#34;2&#34;">`
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"head", `<html><head><title>x</title></head></html>`,
+			`<html><head>` + meta + `<title>x</title></head></html>`},
+		{"head with attributes", `<HEAD lang="en"><title>x</title></HEAD>`,
+			`<HEAD lang="en">` + meta + `<title>x</title></HEAD>`},
+		{"header is not the head", `<header>x</header>`,
+			meta + "\n" + `<header>x</header>`},
+		{"no head", `<p>x</p>`,
+			meta + "\n" + `<p>x</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(addPolicyMeta([]byte(tt.in), policy)); got != tt.want {
+				t.Errorf("addPolicyMeta(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	page := &Page{Files: FileDetails{CSP: map[string][]string{
+		"frame-src":  {"https://www.youtube.com"},
+		"script-src": {"'self'", "https://cdn.example.com"},
+	}}}
+	want := "base-uri 'self'; default-src 'self'; frame-src https://www.youtube.com; object-src 'none'; script-src 'self' https://cdn.example.com"
+	if got := Policy(page, []byte(`<html><head></head></html>`)); got != want {
+		t.Errorf("Policy\n got %s\nwant %s", got, want)
+	}
+
+	// the hash allowed is the one of the script element the browser sees
+	data := addInjectScript([]byte(`<html><head></head><body></body></html>`))
+	m := regexp.MustCompile(`(?s)<script[^>]*>(.*?)</script>`).FindSubmatch(data)
+	if m == nil {
+		t.Fatalf("no script in %s", data)
+	}
+	sum := sha256.Sum256(m[1])
+	hash := "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+	if hash != scriptHash(InjectScript) {
+		t.Errorf("injected script hashes to %s, scriptHash(InjectScript) is %s", hash, scriptHash(InjectScript))
+	}
+	policy := Policy(page, data)
+	if !strings.Contains(policy, "script-src 'self' "+hash+" https://cdn.example.com") {
+		t.Errorf("policy %s does not allow the injected script %s", policy, hash)
+	}
+	if strings.Contains(Policy(page, []byte(`<html></html>`)), "sha256-") {
+		t.Errorf("policy allows a script the page does not carry")
+	}
+}
@@ -0,0 +1,183 @@
+package linker
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	editEqual = iota
+	editDelete
+	editInsert
+)
+
+type edit struct {
+	kind int
+	line string
+}
+
+// UnifiedDiff returns the unified diff of the lines of a and b with context lines around
+// each change, it is empty when they are equal
+func UnifiedDiff(a []byte, b []byte, fromName string, toName string, context int) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	// walk the edits keeping the line numbers of both sides, each hunk spans the changes that
+	// are less than 2*context equal lines apart
+	for start := 0; start < len(edits); {
+		for start < len(edits) && edits[start].kind == editEqual {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		from := start - context
+		if from < 0 {
+			from = 0
+		}
+		end := start
+		for end < len(edits) {
+			if edits[end].kind != editEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].kind == editEqual {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+		to := end + context
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		aStart, bStart := 1, 1
+		for _, e := range edits[:from] {
+			if e.kind != editInsert {
+				aStart++
+			}
+			if e.kind != editDelete {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, e := range edits[from:to] {
+			if e.kind != editInsert {
+				aLen++
+			}
+			if e.kind != editDelete {
+				bLen++
+			}
+		}
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, e := range edits[from:to] {
+			prefix := " "
+			switch e.kind {
+			case editDelete:
+				prefix = "-"
+			case editInsert:
+				prefix = "+"
+			}
+			out.WriteString(prefix + e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines is the Myers shortest edit script from a to b
+func diffLines(a []string, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	var d int
+search:
+	for d = 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// backtrack through the saved frontiers, collecting the edits in reverse
+	var edits []edit
+	x, y := n, m
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{editEqual, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, edit{editInsert, b[y-1]})
+			y--
+		} else {
+			edits = append(edits, edit{editDelete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, edit{editEqual, a[x-1]})
+		x--
+		y--
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
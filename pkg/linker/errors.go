@@ -0,0 +1,56 @@
+package linker
+
+import (
+	"fmt"
+)
+
+// the stage of linking or rendering an Error was raised in
+const (
+	OpDescriptor = "descriptor"
+	OpSchema     = "schema"
+	OpLink       = "link"
+	OpContent    = "content"
+	OpTemplate   = "template"
+	OpExecute    = "execute"
+	OpWrite      = "write"
+)
+
+// the kinds of Error, a caller can tell bad input from a broken template or a failing disk
+const (
+	KindInput  = "input"
+	KindRender = "render"
+	KindIO     = "io"
+)
+
+// Error describes a failure for a single component of the flow
+type Error struct {
+	ID        string
+	Name      string
+	Reference string
+	Op        string
+	Err       error
+}
+
+// NewError returns the Error of stage op for component c
+func NewError(c Component, op string, err error) *Error {
+	return &Error{ID: c.ID, Name: c.Name, Reference: c.Reference, Op: op, Err: err}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s [%s %s] %v", e.Op, e.Reference, e.Name, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Kind classifies the error by the stage it was raised in
+func (e *Error) Kind() string {
+	switch e.Op {
+	case OpTemplate, OpExecute:
+		return KindRender
+	case OpWrite:
+		return KindIO
+	}
+	return KindInput
+}
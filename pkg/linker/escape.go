@@ -0,0 +1,60 @@
+package linker
+
+import (
+	"html/template"
+	"strings"
+)
+
+const injectPrefix = "javascript:injectParams('"
+
+// jsURLEscaper percent encodes everything that could end the quoted injectParams argument
+// or the href attribute, these are never meaningful unescaped in a url
+var jsURLEscaper = strings.NewReplacer(
+	`'`, "%27",
+	`"`, "%22",
+	`\`, "%5C",
+	"`", "%60",
+	"<", "%3C",
+	">", "%3E",
+	" ", "%20",
+	"\n", "%0A",
+	"\r", "%0D",
+	"\t", "%09",
+)
+
+// InjectParams returns the javascript: url calling injectParams with u. Browsers percent
+// decode a javascript: url before running it, so the quote breaking characters are percent
+// encoded and then every % is encoded once more, after decoding the argument is u with no
+// raw quote in it and a crafted campaign name (x%27);alert(1)) can not break out of the call
+func InjectParams(u string) template.URL {
+	arg := strings.Replace(jsURLEscaper.Replace(u), "%", "%25", -1)
+	return template.URL(injectPrefix + arg + "');")
+}
+
+// funcs are the functions available in template.html
+var funcs = template.FuncMap{
+	"injectParams":       InjectParams,
+	"injectParamsScript": InjectScriptTag,
+}
+
+// trustLinks types the resolved links for html/template, injectParams calls are built by
+// InjectParams and can be emitted as they are, plain urls are left to the url sanitizer
+func trustLinks(data map[string]interface{}, links map[string]string) {
+	for slot, url := range links {
+		if strings.HasPrefix(url, injectPrefix) {
+			data[slot] = template.URL(url)
+		}
+	}
+}
+
+// trustHTML marks the named fields as html that is emitted without escaping, a template name
+// of fields trusts the content key it maps to as well, so {{ .Quote }} and {{ .quote }} agree
+func trustHTML(data map[string]interface{}, names []string, fields map[string]string) {
+	for _, name := range names {
+		for _, key := range []string{name, fields[name]} {
+			if value, ok := data[key].(string); ok {
+				data[key] = template.HTML(value)
+			}
+		}
+	}
+}
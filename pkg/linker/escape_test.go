@@ -0,0 +1,90 @@
+package linker
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	hrefValue = regexp.MustCompile(`<a href="([^"]*)">`)
+	// a single injectParams call whose argument holds no quote or backslash to break out with
+	injectCall = regexp.MustCompile(`^javascript:injectParams\('[^'"\\<>\n]*'\);$`)
+)
+
+// escapeFlow links a sales page to a thank you page, campaign is the utm_campaign of the links
+func escapeFlow(campaign string) *Flow {
+	return &Flow{Components: []Component{
+		{ID: "v1", Component: "comment", Name: campaign, Reference: "utm_campaign"},
+		{ID: "v2", Component: "comment", Name: "https://example.com/", Reference: "base_url"},
+		{ID: "p1", Component: "page", Name: "offer", Reference: "Offer",
+			Connections: Connection{"0": {{Index: "0", ID: "p2"}}},
+			Options:     OptionDetails{Template: `{"output":"offer.html","content":"content.json","pagename":"offer","pagetype":"sales","html":["Quote"]}`}},
+		{ID: "p2", Component: "page", Name: "thanks", Reference: "Thanks",
+			Options: OptionDetails{Template: `{"output":"thanks.html","content":"content.json","pagename":"thanks","pagetype":"thankyou"}`}},
+	}}
+}
+
+func TestInjectParamsEscaping(t *testing.T) {
+	src := &Source{
+		Template: []byte(`<h1>{{ .Title }}</h1><q>{{ .Quote }}</q><p>{{ .quote }}</p><a href="{{ .CTAUrl }}">go</a>`),
+		Content:  []byte(`{"title": "<script>alert(1)</script>", "quote": "<b>bold</b>"}`),
+	}
+	campaigns := []string{
+		`spring sale`,
+		`x');alert(1)//`,
+		`x%27);alert(1)//`,
+		`"><script>alert(1)</script>`,
+		"line\nbreak\r\ttab",
+		`back\slash` + "`tick`",
+		`x%2527);alert(1)//`,
+	}
+	for _, campaign := range campaigns {
+		t.Run(campaign, func(t *testing.T) {
+			result, err := Link(escapeFlow(campaign), Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			page := result.Pages[0]
+			if page.Err != nil {
+				t.Fatal(page.Err)
+			}
+			data, err := RenderSource(page, src, Options{Inject: InjectOff})
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := string(data)
+
+			m := hrefValue.FindStringSubmatch(out)
+			if m == nil {
+				t.Fatalf("no link in %s", out)
+			}
+			// the browser unescapes the attribute, then percent decodes the javascript: url once
+			decoded, err := url.PathUnescape(html.UnescapeString(m[1]))
+			if err != nil {
+				t.Fatalf("decoding %s %v", m[1], err)
+			}
+			if !injectCall.MatchString(decoded) {
+				t.Fatalf("href decodes to %q, not a single injectParams call", decoded)
+			}
+			// the argument is the link itself, with the campaign intact
+			arg := strings.TrimSuffix(strings.TrimPrefix(decoded, injectPrefix), "');")
+			u, err := url.Parse(arg)
+			if err != nil {
+				t.Fatalf("parsing argument %q %v", arg, err)
+			}
+			if got := u.Query().Get("utm_campaign"); got != campaign {
+				t.Errorf("utm_campaign %q, want %q", got, campaign)
+			}
+
+			if strings.Contains(out, "<script>") || !strings.Contains(out, "<h1>&lt;script&gt;alert(1)&lt;/script&gt;</h1>") {
+				t.Errorf("Title is not escaped in %s", out)
+			}
+			if !strings.Contains(out, "<q><b>bold</b></q>") || !strings.Contains(out, "<p><b>bold</b></p>") {
+				t.Errorf("trusted Quote is escaped in %s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package linker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// GraphOptions control the diagram output
+type GraphOptions struct {
+	// Tabs groups the pages of each designer tab in its own subgraph
+	Tabs bool
+}
+
+// WriteDOT writes the linked flow as a Graphviz digraph, the resolved url of each
+// connection is its edge tooltip
+func WriteDOT(w io.Writer, flow *Flow, result *Result, opts GraphOptions) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph funnel {")
+	fmt.Fprintln(b, "\trankdir=LR;")
+	fmt.Fprintln(b, "\tnode [shape=box];")
+	for _, group := range groupPages(flow, result, opts) {
+		indent := "\t"
+		if group.tab != "" {
+			fmt.Fprintf(b, "\tsubgraph %s {\n", dotQuote("cluster_"+group.tab))
+			fmt.Fprintf(b, "\t\tlabel=%s;\n", dotQuote(group.name))
+			indent = "\t\t"
+		}
+		for _, page := range group.pages {
+			fmt.Fprintf(b, "%s%s [label=%s];\n", indent, dotQuote(page.Component.ID), dotQuote(nodeLabel(page, "\n")))
+		}
+		if group.tab != "" {
+			fmt.Fprintln(b, "\t}")
+		}
+	}
+	for _, page := range result.Pages {
+		for _, edge := range page.Edges {
+			fmt.Fprintf(b, "\t%s -> %s [label=%s, tooltip=%s];\n", dotQuote(page.Component.ID), dotQuote(edge.To), dotQuote(edge.Slot), dotQuote(edge.URL))
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// WriteMermaid writes the linked flow as a Mermaid flowchart, the resolved url of each
+// connection follows its edge as a comment
+func WriteMermaid(w io.Writer, flow *Flow, result *Result, opts GraphOptions) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "flowchart LR")
+	for _, group := range groupPages(flow, result, opts) {
+		indent := "    "
+		if group.tab != "" {
+			fmt.Fprintf(b, "    subgraph %s [%s]\n", mermaidID("tab_"+group.tab), mermaidQuote(group.name))
+			indent = "        "
+		}
+		for _, page := range group.pages {
+			fmt.Fprintf(b, "%s%s[%s]\n", indent, mermaidID(page.Component.ID), mermaidQuote(nodeLabel(page, "<br/>")))
+		}
+		if group.tab != "" {
+			fmt.Fprintln(b, "    end")
+		}
+	}
+	for _, page := range result.Pages {
+		for _, edge := range page.Edges {
+			fmt.Fprintf(b, "    %s -->|%s| %s\n", mermaidID(page.Component.ID), mermaidQuote(edge.Slot), mermaidID(edge.To))
+			fmt.Fprintf(b, "    %%%% %s\n", strings.Replace(edge.URL, "\n", " ", -1))
+		}
+	}
+	return b.Flush()
+}
+
+type pageGroup struct {
+	tab   string
+	name  string
+	pages []*Page
+}
+
+// groupPages returns the pages in flow order, split by tab when asked to
+func groupPages(flow *Flow, result *Result, opts GraphOptions) []pageGroup {
+	if !opts.Tabs {
+		return []pageGroup{{pages: result.Pages}}
+	}
+	var groups []pageGroup
+	index := map[string]int{}
+	for _, tab := range flow.Tabs {
+		index[tab.ID] = len(groups)
+		groups = append(groups, pageGroup{tab: tab.ID, name: tab.Name})
+	}
+	var untabbed []*Page
+	for _, page := range result.Pages {
+		if i, ok := index[page.Component.Tab]; ok {
+			groups[i].pages = append(groups[i].pages, page)
+		} else {
+			untabbed = append(untabbed, page)
+		}
+	}
+	if len(untabbed) > 0 {
+		groups = append(groups, pageGroup{pages: untabbed})
+	}
+	return groups
+}
+
+func nodeLabel(page *Page, sep string) string {
+	label := page.Component.Reference
+	if page.Component.Name != "" {
+		label += sep + page.Component.Name
+	}
+	if page.Files.Pagetype != "" {
+		label += sep + "(" + page.Files.Pagetype + ")"
+	}
+	return label
+}
+
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func mermaidID(s string) string {
+	return "n_" + mermaidUnsafe.ReplaceAllString(s, "_")
+}
+
+func mermaidQuote(s string) string {
+	s = strings.Replace(s, `"`, "#quot;", -1)
+	s = strings.Replace(s, "|", "#124;", -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,93 @@
+package linker
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+)
+
+// InjectScriptVersion is the version of the injectParams helper, it is carried by the
+// script tag so a page shows which helper it was rendered with
+const InjectScriptVersion = "2"
+
+// the ways the injectParams helper gets into a page
+const (
+	// InjectAuto adds the helper to the pages that call injectParams and do not define it
+	InjectAuto = "auto"
+	// InjectOff leaves it to template.html, with {{ injectParamsScript }} or a script of its own
+	InjectOff = "off"
+)
+
+// InjectScript is the injectParams helper. It carries forward the query parameters the page
+// was opened with (gclid, fbclid ...) that a link does not set itself, the linker's own
+// tracking parameters win. injectParams(url) follows the url it is given, the hrefs of the
+// anchors marked with data-inject-params are rewritten once the page is loaded
+const InjectScript = `(function () {
+  if (window.injectParams && window.injectParams.urlLinker) { return; }
+  function carry(url) {
+    var next;
+    try {
+      next = new URL(url, window.location.href);
+    } catch (e) {
+      return url;
+    }
+    var own = {};
+    next.searchParams.forEach(function (value, key) { own[key] = true; });
+    new URLSearchParams(window.location.search).forEach(function (value, key) {
+      if (!own[key]) { next.searchParams.append(key, value); }
+    });
+    return next.toString();
+  }
+  function injectParams(url) {
+    window.location.href = carry(url);
+  }
+  function enhance() {
+    var links = document.querySelectorAll("a[data-inject-params]");
+    for (var i = 0; i < links.length; i++) {
+      links[i].href = carry(links[i].getAttribute("href"));
+    }
+  }
+  injectParams.urlLinker = "` + InjectScriptVersion + `";
+  window.injectParams = injectParams;
+  if (document.readyState === "loading") {
+    document.addEventListener("DOMContentLoaded", enhance);
+  } else {
+    enhance();
+  }
+})();`
+
+// injectScriptTag is the script element holding InjectScript
+var injectScriptTag = fmt.Sprintf("<script data-url-linker=\"inject-params\" data-version=%q>\n%s\n</script>\n", InjectScriptVersion, InjectScript)
+
+// InjectScriptTag returns the script element of the injectParams helper, it is the
+// injectParamsScript function of template.html
+func InjectScriptTag() template.HTML {
+	return template.HTML(injectScriptTag)
+}
+
+var (
+	usesInjectParams    = regexp.MustCompile(`javascript:injectParams|<a\s[^>]*data-inject-params`)
+	definesInjectParams = regexp.MustCompile(`data-url-linker="inject-params"|function\s+injectParams\b|injectParams\s*=[^=]`)
+	headEnd             = regexp.MustCompile(`(?i)</head\s*>`)
+	bodyEnd             = regexp.MustCompile(`(?i)</body\s*>`)
+)
+
+// MissingInjectParams reports whether the rendered page has injectParams or data-inject-params
+// links without defining injectParams, its buttons would do nothing or carry nothing. A
+// helper loaded from another file is not seen
+func MissingInjectParams(data []byte) bool {
+	return usesInjectParams.Match(data) && !definesInjectParams.Match(data)
+}
+
+// addInjectScript puts the helper at the end of the head, else of the body, else of the page
+func addInjectScript(data []byte) []byte {
+	for _, re := range []*regexp.Regexp{headEnd, bodyEnd} {
+		if loc := re.FindIndex(data); loc != nil {
+			out := make([]byte, 0, len(data)+len(injectScriptTag))
+			out = append(out, data[:loc[0]]...)
+			out = append(out, injectScriptTag...)
+			return append(out, data[loc[0]:]...)
+		}
+	}
+	return append(data, injectScriptTag...)
+}
@@ -0,0 +1,107 @@
+package linker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// the time per page stays flat as the funnel grows, linking and rendering are linear
+//
+//	go test ./pkg/linker -run '^$' -bench . -benchmem
+var benchSizes = []int{50, 100, 300, 1200}
+
+const benchTemplate = `<h1>{{ .Title }}</h1><a href="{{ .CTAUrl }}">{{ .CTA }}</a>
+<a href="{{ .LinkAUrl }}">a</a><a href="{{ .LinkBUrl }}">b</a><a href="{{ .LinkCUrl }}">c</a>
+`
+
+func BenchmarkLink(b *testing.B) {
+	for _, n := range benchSizes {
+		flow := benchFunnel(n)
+		b.Run(fmt.Sprintf("pages=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Link(flow, Options{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/page")
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("pages=%d", n), func(b *testing.B) {
+			opts := Options{Dir: b.TempDir()}
+			if err := benchPages(opts.Dir, n); err != nil {
+				b.Fatal(err)
+			}
+			result, err := Link(benchFunnel(n), opts)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, out := range Render(result, opts) {
+					if out.Err != nil {
+						b.Fatal(out.Err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/page")
+		})
+	}
+}
+
+// benchFunnel generates a flow of n pages, every page links its four ports to the pages after it
+func benchFunnel(n int) *Flow {
+	flow := &Flow{Components: []Component{
+		{ID: "v1", Component: "comment", Name: "bench", Reference: "utm_campaign"},
+		{ID: "v2", Component: "comment", Name: "https://example.com/", Reference: "base_url"},
+	}}
+	for i := 0; i < n; i++ {
+		name := "page" + strconv.Itoa(i)
+		pagetype := "sales"
+		if i == 0 {
+			pagetype = "origin"
+		}
+		c := Component{
+			ID:          name,
+			Component:   "page",
+			Name:        name,
+			Reference:   "Page" + strconv.Itoa(i),
+			Connections: Connection{},
+			Options: OptionDetails{
+				Template: fmt.Sprintf(`{"output":"index.html","content":"content.json","pagename":%q,"pagetype":%q}`, name, pagetype),
+			},
+		}
+		for port := 0; port < 4 && i+port+1 < n; port++ {
+			c.Connections[strconv.Itoa(port)] = []Wire{{Index: "0", ID: "page" + strconv.Itoa(i+port+1)}}
+		}
+		flow.Components = append(flow.Components, c)
+	}
+	return flow
+}
+
+// benchPages writes the template and content of n generated pages under dir
+func benchPages(dir string, n int) error {
+	for i := 0; i < n; i++ {
+		folder := filepath.Join(dir, "page"+strconv.Itoa(i))
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(folder, TemplateFile), []byte(benchTemplate), 0644); err != nil {
+			return err
+		}
+		content := fmt.Sprintf(`{"title":"Page %d","cta":"next"}`, i)
+		if err := ioutil.WriteFile(filepath.Join(folder, "content.json"), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
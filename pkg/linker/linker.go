@@ -0,0 +1,397 @@
+package linker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Options control how a flow is linked and rendered
+type Options struct {
+	// Dir is the template root holding one folder per page component
+	Dir string
+	// Schema is the page family used when a page descriptor does not name one
+	Schema string
+	// Tracking replaces the profile declared in the flow's Variables and the default one
+	Tracking *TrackingProfile
+	// BaseURL replaces the flow's base_url, the pages then link to another host (a preview)
+	BaseURL string
+	// Inject is how the injectParams helper gets into the pages, InjectAuto when empty
+	Inject string
+	// LinkMode is how the pages of flows that do not choose one emit their links,
+	// LinkPagetype when empty
+	LinkMode string
+	// CSP is how the Content-Security-Policy of the pages is emitted, CSPOff when empty
+	CSP string
+	// SRI adds integrity hashes to the script and stylesheet tags of local assets
+	SRI bool
+	// DenyFlowEnv refuses a tracking profile declared by the flow that reads environment
+	// variables, only Tracking may then. Set it when the flow comes from an untrusted client
+	DenyFlowEnv bool
+}
+
+// Page is a page component with its descriptor and resolved links
+type Page struct {
+	Component Component
+	Files     FileDetails
+	Schema    string
+	// LinkMode is how the page emits its links, from its descriptor, the flow or the Options
+	LinkMode string
+	// Links holds the resolved url of each connected link slot
+	Links map[string]string
+	// Edges are the page's connections to existing components in port order
+	Edges []Edge
+	// Err is set when the page could not be linked, it will not be rendered
+	Err error
+}
+
+// Path is where the rendered page goes, relative to the output root
+func (p *Page) Path() string {
+	return filepath.Join(p.Component.Name, p.Files.Output)
+}
+
+// Edge is a resolved connection of a page
+type Edge struct {
+	Port string
+	Slot string
+	To   string
+	URL  string
+}
+
+// Result is the linked flow, ready to be rendered
+type Result struct {
+	// Variables maps the Reference of every flow component to its Name (utm_campaign, base_url ...)
+	Variables map[string]string
+	Tracking  *TrackingProfile
+	Pages     []*Page
+}
+
+// Output is a rendered page, Path is relative to the template root
+type Output struct {
+	Page *Page
+	Path string
+	Data []byte
+	Err  error
+}
+
+// Errors returns the link errors of every page
+func (r *Result) Errors() []error {
+	var errs []error
+	for _, page := range r.Pages {
+		if page.Err != nil {
+			errs = append(errs, page.Err)
+		}
+	}
+	return errs
+}
+
+// Parse decodes the designer flow json
+func Parse(r io.Reader) (*Flow, error) {
+	var flow Flow
+	if err := json.NewDecoder(r).Decode(&flow); err != nil {
+		return nil, fmt.Errorf("converting designer flow json %v", err)
+	}
+	return &flow, nil
+}
+
+// settings are declared by a flow whose Variables field holds a json object
+type settings struct {
+	Tracking *TrackingProfile `json:"tracking"`
+	LinkMode string           `json:"linkMode"`
+}
+
+// flowSettings decodes the settings of the flow, they are empty when its Variables field
+// is not a json object
+func flowSettings(flow *Flow) (*settings, error) {
+	var decoded settings
+	vars := strings.TrimSpace(flow.Variables)
+	if !strings.HasPrefix(vars, "{") {
+		return &decoded, nil
+	}
+	if err := json.Unmarshal([]byte(vars), &decoded); err != nil {
+		return nil, fmt.Errorf("converting flow variables json %v", err)
+	}
+	if decoded.Tracking != nil {
+		if err := decoded.Tracking.check(); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkLinkMode(decoded.LinkMode); err != nil {
+		return nil, fmt.Errorf("flow variables %v", err)
+	}
+	return &decoded, nil
+}
+
+// Link reads the page descriptors of the flow and resolves the url of every connection
+func Link(flow *Flow, opts Options) (*Result, error) {
+	if flow == nil {
+		return nil, errors.New("no flow to link")
+	}
+	if opts.Schema == "" {
+		opts.Schema = "short"
+	}
+	switch opts.Inject {
+	case "", InjectAuto, InjectOff:
+	default:
+		return nil, fmt.Errorf("unknown inject mode %s (available %s,%s)", opts.Inject, InjectAuto, InjectOff)
+	}
+	if err := checkLinkMode(opts.LinkMode); err != nil {
+		return nil, err
+	}
+	switch opts.CSP {
+	case "", CSPOff, CSPMeta, CSPHeaders:
+	default:
+		return nil, fmt.Errorf("unknown csp mode %s (available %s,%s,%s)", opts.CSP, CSPOff, CSPMeta, CSPHeaders)
+	}
+	if _, ok := LookupSchema(opts.Schema); !ok {
+		return nil, fmt.Errorf("unknown schema %s (available %s)", opts.Schema, strings.Join(SchemaNames(), ","))
+	}
+
+	result := &Result{Variables: map[string]string{}, Tracking: opts.Tracking}
+	var designer []Component
+	for _, comp := range flow.Components {
+		if comp.Component != "comment" {
+			designer = append(designer, comp)
+		}
+		if comp.Reference != "" {
+			result.Variables[comp.Reference] = comp.Name
+		}
+	}
+
+	if opts.BaseURL != "" {
+		result.Variables["base_url"] = opts.BaseURL
+	}
+
+	flowSet, err := flowSettings(flow)
+	if err != nil {
+		return nil, err
+	}
+	if result.Tracking == nil && flowSet.Tracking != nil {
+		if opts.DenyFlowEnv && flowSet.Tracking.readsEnv() {
+			return nil, errors.New("the tracking profile of the flow may not read environment variables")
+		}
+		result.Tracking = flowSet.Tracking
+	}
+	if result.Tracking == nil {
+		result.Tracking = DefaultTracking
+	}
+
+	base, err := url.Parse(result.Variables["base_url"])
+	if err != nil {
+		return nil, fmt.Errorf("parsing base_url %v", err)
+	}
+
+	// every descriptor is parsed once and indexed by component ID, connections then look
+	// their target up instead of scanning the designer for every link
+	index := make(map[string]*Page, len(designer))
+	unparsed := map[string]error{}
+	for from := range designer {
+		page := &Page{Component: designer[from]}
+		result.Pages = append(result.Pages, page)
+		if _, ok := index[page.Component.ID]; !ok {
+			index[page.Component.ID] = page
+		}
+		if err := json.Unmarshal([]byte(page.Component.Options.Template), &page.Files); err != nil {
+			unparsed[page.Component.ID] = err
+			page.Err = NewError(page.Component, OpDescriptor, fmt.Errorf("converting embedded file data json %v", err))
+		}
+	}
+
+	for _, page := range result.Pages {
+		if page.Err != nil {
+			continue
+		}
+		if err := checkPaths(page); err != nil {
+			page.Err = NewError(page.Component, OpDescriptor, err)
+			continue
+		}
+		// the page family comes from the embedded template json, falling back to the options
+		page.Schema = page.Files.Schema
+		if page.Schema == "" {
+			page.Schema = opts.Schema
+		}
+		def, ok := LookupSchema(page.Schema)
+		if !ok {
+			page.Err = NewError(page.Component, OpSchema, fmt.Errorf("unknown schema %s", page.Schema))
+			continue
+		}
+		page.LinkMode = firstOf(page.Files.LinkMode, flowSet.LinkMode, opts.LinkMode, LinkPagetype)
+		if err = checkLinkMode(page.Files.LinkMode); err != nil {
+			page.Err = NewError(page.Component, OpDescriptor, err)
+			continue
+		}
+
+		page.Links = map[string]string{}
+		for _, port := range page.Component.Connections.Ports() {
+			for n, link := range page.Component.Connections[port] {
+				var slot string
+				if page.Files.Links != nil {
+					slot, err = page.Files.Slot(link.SlotName())
+				} else {
+					slot, err = def.Slot(port, n)
+				}
+				if err == nil {
+					if _, ok := page.Links[slot]; ok {
+						err = fmt.Errorf("link slot %s is connected more than once", slot)
+					}
+				}
+				if err != nil {
+					page.Err = NewError(page.Component, OpLink, err)
+					break
+				}
+				target, ok := index[link.ID]
+				if !ok {
+					page.Links[slot] = ""
+					continue
+				}
+				if err = unparsed[link.ID]; err != nil {
+					page.Err = NewError(page.Component, OpLink, fmt.Errorf("converting embedded file [to] data json %v %s", err, target.Component.Reference+" "+target.Component.Name))
+					break
+				}
+				page.Links[slot] = linkURL(base, result.Tracking.params(result.Variables, page, slot), page, &target.Component, target.Files)
+				page.Edges = append(page.Edges, Edge{Port: port, Slot: slot, To: target.Component.ID, URL: page.Links[slot]})
+			}
+			if page.Err != nil {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// checkPaths keeps the files of the page inside its folder under the template root, the
+// folder name, output and content may not be absolute or climb out with .. and the output
+// may not overwrite a source file
+func checkPaths(page *Page) error {
+	for _, p := range []struct{ what, name string }{
+		{"name", page.Component.Name},
+		{"output", page.Files.Output},
+		{"content", page.Files.Content},
+	} {
+		clean := filepath.Clean(p.name)
+		if filepath.IsAbs(p.name) || strings.HasPrefix(p.name, "/") || strings.HasPrefix(p.name, `\`) ||
+			clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s %s leaves the page folder", p.what, p.name)
+		}
+	}
+	for _, name := range page.Sources() {
+		if filepath.Clean(page.Files.Output) == name {
+			return fmt.Errorf("output %s would overwrite a source file", page.Files.Output)
+		}
+	}
+	return nil
+}
+
+// linkURL builds the url from a page to the target of one of its connections
+func linkURL(base *url.URL, params []Param, page *Page, target *Component, filesTo FileDetails) string {
+	url := BuildURL(base, []string{target.Name, filesTo.Output}, params)
+	switch page.LinkMode {
+	case LinkJavascript:
+		url = string(InjectParams(url))
+	case LinkPagetype:
+		if page.Files.Pagetype != "origin" {
+			url = string(InjectParams(url))
+		}
+	}
+	return url
+}
+
+// Render renders every page of the linked flow, pages that fail carry their error in the Output
+func Render(result *Result, opts Options) []*Output {
+	var outputs []*Output
+	for _, page := range result.Pages {
+		out := &Output{Page: page, Path: page.Path()}
+		if page.Err != nil {
+			out.Err = page.Err
+		} else {
+			out.Data, out.Err = RenderPage(page, opts)
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs
+}
+
+// Source holds the template and content json of a page as read from its folder
+type Source struct {
+	Template []byte
+	Content  []byte
+}
+
+// LoadPage reads the page's template.html and content json
+func LoadPage(page *Page, opts Options) (*Source, error) {
+	if page.Err != nil {
+		return nil, page.Err
+	}
+	dir := filepath.Join(opts.Dir, page.Component.Name)
+	content, err := ioutil.ReadFile(filepath.Join(dir, page.Files.Content))
+	if err != nil {
+		return nil, NewError(page.Component, OpContent, fmt.Errorf("no json content file found %v", err))
+	}
+	html, err := ioutil.ReadFile(filepath.Join(dir, TemplateFile))
+	if err != nil {
+		return nil, NewError(page.Component, OpContent, fmt.Errorf("no template found %v", err))
+	}
+	return &Source{Template: html, Content: content}, nil
+}
+
+// RenderPage loads the page's template.html and content json and executes the template
+func RenderPage(page *Page, opts Options) ([]byte, error) {
+	src, err := LoadPage(page, opts)
+	if err != nil {
+		return nil, err
+	}
+	return RenderSource(page, src, opts)
+}
+
+// RenderSource executes the page's template with its content and resolved links
+func RenderSource(page *Page, src *Source, opts Options) ([]byte, error) {
+	def, ok := LookupSchema(page.Schema)
+	if !ok {
+		return nil, NewError(page.Component, OpSchema, fmt.Errorf("unknown schema %s", page.Schema))
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(src.Content, &fields); err != nil {
+		return nil, NewError(page.Component, OpContent, fmt.Errorf("unmarshalling data %v", err))
+	}
+	tmpl, err := template.New("transform").Funcs(funcs).Parse(string(src.Template))
+	if err != nil {
+		return nil, NewError(page.Component, OpTemplate, fmt.Errorf("creating transform %v", err))
+	}
+
+	// we add in our pagename and pagetype variables
+	if page.Files.Pagename == "" || page.Files.Pagetype == "" {
+		return nil, NewError(page.Component, OpDescriptor, errors.New("please ensure pagename and pagetype variables are included in the page"))
+	}
+	htmlschema := def.Data(fields, page.Links)
+	trustLinks(htmlschema, page.Links)
+	trustHTML(htmlschema, def.HTML, def.Fields)
+	trustHTML(htmlschema, page.Files.HTML, def.Fields)
+	htmlschema["Pagename"] = page.Files.Pagename
+	htmlschema["Pagetype"] = page.Files.Pagetype
+
+	var data bytes.Buffer
+	if err = tmpl.Execute(&data, htmlschema); err != nil {
+		return nil, NewError(page.Component, OpExecute, fmt.Errorf("executing transform %v", err))
+	}
+	out := data.Bytes()
+	if page.LinkMode == LinkEnhanced {
+		out = markLinks(out, page.Links)
+	}
+	if opts.Inject != InjectOff && MissingInjectParams(out) {
+		out = addInjectScript(out)
+	}
+	if opts.SRI {
+		out = addIntegrity(out, filepath.Join(opts.Dir, page.Component.Name))
+	}
+	if opts.CSP == CSPMeta {
+		out = addPolicyMeta(out, Policy(page, out))
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package linker
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+)
+
+// the ways a page emits its links, chosen by the page descriptor's linkMode, else the flow's,
+// else Options.LinkMode
+const (
+	// LinkPagetype links from the origin page plainly and from every other page through
+	// javascript:injectParams, the behaviour of the linker before link modes
+	LinkPagetype = "pagetype"
+	// LinkJavascript emits every link as javascript:injectParams('<url>')
+	LinkJavascript = "javascript"
+	// LinkEnhanced emits the real url in the href and marks the anchor with a
+	// data-inject-params attribute, the helper script adds the carried parameters to it.
+	// Links work without javascript, open in new tabs and are seen by crawlers
+	LinkEnhanced = "enhanced"
+	// LinkPlain emits the real url and carries nothing forward
+	LinkPlain = "plain"
+)
+
+// LinkModes are the valid link modes
+var LinkModes = []string{LinkPagetype, LinkJavascript, LinkEnhanced, LinkPlain}
+
+// checkLinkMode accepts the link modes and the empty mode
+func checkLinkMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, m := range LinkModes {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown link mode %s (available %v)", mode, LinkModes)
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var (
+	anchorTag = regexp.MustCompile(`(?is)<a\s[^>]*>`)
+	hrefAttr  = regexp.MustCompile(`(?is)\shref\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	markAttr  = regexp.MustCompile(`(?is)\sdata-inject-params\b`)
+)
+
+// markLinks adds the data-inject-params attribute to the anchors whose href is one of the
+// resolved links, the other anchors of the page are left alone
+func markLinks(data []byte, links map[string]string) []byte {
+	urls := map[string]bool{}
+	for _, url := range links {
+		if url != "" {
+			urls[url] = true
+		}
+	}
+	return anchorTag.ReplaceAllFunc(data, func(tag []byte) []byte {
+		m := hrefAttr.FindSubmatch(tag)
+		if m == nil || markAttr.Match(tag) {
+			return tag
+		}
+		href := string(m[1]) + string(m[2])
+		if !urls[html.UnescapeString(href)] {
+			return tag
+		}
+		// <a becomes <a data-inject-params
+		out := make([]byte, 0, len(tag)+len(" data-inject-params"))
+		out = append(out, tag[:2]...)
+		out = append(out, " data-inject-params"...)
+		return append(out, tag[2:]...)
+	})
+}
@@ -0,0 +1,56 @@
+package linker
+
+import "testing"
+
+func TestMarkLinks(t *testing.T) {
+	links := map[string]string{
+		"CTAUrl":  "https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing",
+		"NextUrl": "",
+	}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"double quoted href",
+			`<a href="https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing">go</a>`,
+			`<a data-inject-params href="https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing">go</a>`},
+		{"single quoted href",
+			`<a class="btn" href='https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing'>go</a>`,
+			`<a data-inject-params class="btn" href='https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing'>go</a>`},
+		{"entity escaped href",
+			`<a href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">go</a>`,
+			`<a data-inject-params href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">go</a>`},
+		{"upper case tag and spaced href",
+			`<A HREF = "https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">go</A>`,
+			`<A data-inject-params HREF = "https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">go</A>`},
+		{"already marked",
+			`<a data-inject-params href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">go</a>`,
+			`<a data-inject-params href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">go</a>`},
+		{"already marked after the href",
+			`<a href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing" data-inject-params="">go</a>`,
+			`<a href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing" data-inject-params="">go</a>`},
+		{"not a resolved link",
+			`<a href="https://example.com/faq.html">faq</a>`,
+			`<a href="https://example.com/faq.html">faq</a>`},
+		{"resolved link with another query",
+			`<a href="https://example.com/offer/index.html?utm_campaign=spring">go</a>`,
+			`<a href="https://example.com/offer/index.html?utm_campaign=spring">go</a>`},
+		{"empty href of an unresolved slot",
+			`<a href="">next</a>`,
+			`<a href="">next</a>`},
+		{"anchor without href",
+			`<a name="top">top</a>`,
+			`<a name="top">top</a>`},
+		{"not an anchor",
+			`<abbr href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">x</abbr>`,
+			`<abbr href="https://example.com/offer/index.html?utm_campaign=spring&amp;utm_source=landing">x</abbr>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(markLinks([]byte(tt.in), links)); got != tt.want {
+				t.Errorf("markLinks(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
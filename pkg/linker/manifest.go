@@ -0,0 +1,103 @@
+package linker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Version of the linker, it is part of every page hash so pages rendered by an older
+// linker are rebuilt. Bump it whenever the rendered output changes
+const Version = "1.5.1"
+
+// ManifestFile is the build manifest kept at the root of the output directory
+const ManifestFile = ".url-linker-manifest.json"
+
+// Manifest records what every page was last built from, keyed by Page.Path
+type Manifest struct {
+	Version string                   `json:"version"`
+	Pages   map[string]ManifestEntry `json:"pages"`
+}
+
+// ManifestEntry holds the hash of a page's inputs and of the output written from them, the
+// file mode it was written with and the hash of the sidecar headers file written for it
+type ManifestEntry struct {
+	Inputs  string      `json:"inputs"`
+	Output  string      `json:"output"`
+	Mode    os.FileMode `json:"mode"`
+	Headers string      `json:"headers,omitempty"`
+}
+
+// NewManifest returns an empty manifest of the current Version
+func NewManifest() *Manifest {
+	return &Manifest{Version: Version, Pages: map[string]ManifestEntry{}}
+}
+
+// ReadManifest loads the manifest file, a missing file or one of another Version is an
+// empty manifest so everything is rebuilt
+func ReadManifest(name string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return NewManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		return NewManifest(), fmt.Errorf("converting build manifest json %v", err)
+	}
+	if m.Version != Version || m.Pages == nil {
+		return NewManifest(), nil
+	}
+	return &m, nil
+}
+
+// Write atomically replaces the manifest file
+func (m *Manifest) Write(name string) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return WriteFile(name, append(data, '\n'), WriteOptions{})
+}
+
+// UpToDate reports whether the page at path was built from inputs with mode and output
+// still holds what was written then
+func (m *Manifest) UpToDate(path string, inputs string, output []byte, mode os.FileMode) bool {
+	entry, ok := m.Pages[path]
+	return ok && entry.Inputs == inputs && entry.Output == Hash(output) && entry.Mode == mode
+}
+
+// Hash is the hex sha256 of data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// InputHash hashes everything the rendering of the page depends on: the linker Version and
+// options, the page descriptor, link mode and schema definition, the resolved links, the
+// template and the content, and with SRI the static assets the integrity hashes are made of
+func InputHash(page *Page, src *Source, opts Options) string {
+	def, _ := LookupSchema(page.Schema)
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	// maps are encoded with sorted keys so the hash is stable
+	for _, v := range []interface{}{Version, opts.Inject, opts.CSP, opts.SRI, page.Files, page.LinkMode, page.Schema, def, page.Links, src.Template, src.Content} {
+		enc.Encode(v)
+	}
+	if opts.SRI {
+		walkAssets(page, filepath.Join(opts.Dir, page.Component.Name), func(name string, rel string, info os.FileInfo) error {
+			if data, err := ioutil.ReadFile(name); err == nil {
+				enc.Encode(rel)
+				enc.Encode(Hash(data))
+			}
+			return nil
+		})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,146 @@
+package linker
+
+// the "long" page family, port 0 fills the eight options urls and ports 1 and 2 the
+// yes/no option urls
+const longSchema = `{
+	"name": "long",
+	"ports": {
+		"0": ["OptionsAUrl", "OptionsBUrl", "OptionsCUrl", "OptionsDUrl", "OptionsEUrl", "OptionsFUrl", "OptionsGUrl", "OptionsHUrl"],
+		"1": ["OptionAUrl"],
+		"2": ["OptionBUrl"]
+	},
+	"fields": {
+		"Title": "title",
+		"TitleDescription": "titleDescription",
+		"TitleUrl": "titleUrl",
+		"TitleBtn": "titleBtn",
+		"Info": "info",
+		"InfoDescription": "infodescription",
+		"InfoA": "infoA",
+		"InfoADescription": "infoAdescription",
+		"InfoB": "infoB",
+		"InfoBDescription": "infoBdescription",
+		"InfoC": "infoC",
+		"InfoCDescription": "infoCdescription",
+		"DetailDescription": "detailDescription",
+		"Overview": "overview",
+		"OverviewDescription": "overviewDescription",
+		"ImageOptionsA": "imageOptionsA",
+		"OptionsA": "optionsA",
+		"OptionsATitle": "optionsATitle",
+		"OptionsABtn": "optionsABtn",
+		"OptionsAUrl": "optionsAUrl",
+		"OptionsADescription": "optionsADescription",
+		"OptionsB": "optionsB",
+		"OptionsBTitle": "optionsBTitle",
+		"OptionsBBtn": "optionsBBtn",
+		"OptionsBUrl": "optionsBUrl",
+		"OptionsBDescription": "optionsBDescription",
+		"OptionsC": "optionsC",
+		"OptionsCTitle": "optionsCTitle",
+		"OptionsCBtn": "optionsCBtn",
+		"OptionsCUrl": "optionsCUrl",
+		"OptionsCDescription": "optionsCDescription",
+		"OptionsD": "optionsD",
+		"OptionsDTitle": "optionsDTitle",
+		"OptionsDBtn": "optionsDBtn",
+		"OptionsDUrl": "optionsDUrl",
+		"OptionsDDescription": "optionsDDescription",
+		"OptionsE": "optionsE",
+		"OptionsETitle": "optionsETitle",
+		"OptionsEBtn": "optionsEBtn",
+		"OptionsEUrl": "optionsEUrl",
+		"OptionsEDescription": "optionsEDescription",
+		"OptionsF": "optionsF",
+		"OptionsFTitle": "optionsFTitle",
+		"OptionsFBtn": "optionsFBtn",
+		"OptionsFUrl": "optionsFUrl",
+		"OptionsFDescription": "optionsFDescription",
+		"OptionsG": "optionsG",
+		"OptionsGTitle": "optionsGTitle",
+		"OptionsGBtn": "optionsGBtn",
+		"OptionsGUrl": "optionsGUrl",
+		"OptionsGDescription": "optionsGDescription",
+		"OptionsH": "optionsH",
+		"OptionsHTitle": "optionsHTitle",
+		"OptionsHBtn": "optionsHBtn",
+		"OptionsHUrl": "optionsHUrl",
+		"OptionsHDescription": "optionsHDescription",
+		"ImageOptionsB": "imageOptionsB",
+		"Video": "video",
+		"VideoDescription": "videoDescription",
+		"Contact": "contact",
+		"Valued": "valued",
+		"ValuedDescription": "valueddescription",
+		"Description": "description",
+		"ValuedUrl": "valuedurl",
+		"ValuedBtn": "valuedbtn",
+		"NextUrl": "nexturl",
+		"NextBtn": "nextbtn",
+		"Service": "service",
+		"ServiceDescription": "servicedescription",
+		"OptionA": "optionA",
+		"OptionAUrl": "optionAUrl",
+		"OptionADescription": "optionADescription",
+		"OptionB": "optionB",
+		"OptionBUrl": "optionBUrl",
+		"OptionBDescription": "optionBDescription",
+		"SubOption": "subOption",
+		"SubOptionDescription": "subOptionDescription",
+		"SubOptionA": "subOptionA",
+		"SubOptionATitle": "subOptionATitle",
+		"SubOptionADescription": "subOptionADescription",
+		"SubOptionAUrl": "subOptionAUrl",
+		"SubOptionABtn": "subOptionABtn",
+		"SubOptionB": "subOptionB",
+		"SubOptionBTitle": "subOptionBTitle",
+		"SubOptionBDescription": "subOptionBDescription",
+		"SubOptionBUrl": "subOptionBUrl",
+		"SubOptionBBtn": "subOptionBBtn",
+		"SubOptionC": "subOptionC",
+		"SubOptionCTitle": "subOptionCTitle",
+		"SubOptionCDescription": "subOptionCDescription",
+		"SubOptionCUrl": "subOptionCUrl",
+		"SubOptionCBtn": "subOptionCBtn",
+		"SubOptionDDescription": "subOptionDDescription",
+		"SubOptionDUrl": "subOptionDUrl",
+		"SubOptionDBtn": "subOptionDBtn",
+		"DataA": "dataA",
+		"DataATitle": "dataATitle",
+		"DataB": "dataB",
+		"DataBTitle": "dataBTitle",
+		"DataC": "dataC",
+		"DataCTitle": "dataCTitle",
+		"Pricing": "pricing",
+		"PricingDescription": "pricingDescription",
+		"PlanA": "planA",
+		"PlanADescription": "planADescription",
+		"PlanADetails": "planADetails",
+		"PlanAUrl": "planAUrl",
+		"PlanABtn": "planABtn",
+		"AS": "AS",
+		"AP": "AP",
+		"AM": "AM",
+		"PlanB": "planB",
+		"PlanBDescription": "planBDescription",
+		"PlanBDetails": "planBDetails",
+		"PlanBUrl": "planBUrl",
+		"PlanBBtn": "planBBtn",
+		"BS": "BS",
+		"BP": "BP",
+		"BM": "BM",
+		"Address": "address",
+		"AboutDescription": "aboutdescription",
+		"Phone": "phone",
+		"LinkAUrl": "linkaurl",
+		"LinkBUrl": "linkburl",
+		"LinkCUrl": "linkcurl",
+		"LinkABtn": "linkabtn",
+		"LinkBBtn": "linkbbtn",
+		"LinkCBtn": "linkcbtn"
+	}
+}`
+
+func init() {
+	RegisterSchema(mustParseDefinition(longSchema))
+}
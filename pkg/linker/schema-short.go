@@ -0,0 +1,92 @@
+package linker
+
+// the "short" page family, port 0 fills the CTA followed by the four data urls and
+// ports 1 to 3 the secondary link buttons
+const shortSchema = `{
+	"name": "short",
+	"ports": {
+		"0": ["CTAUrl", "DataAUrl", "DataBUrl", "DataCUrl", "DataDUrl"],
+		"1": ["LinkAUrl"],
+		"2": ["LinkBUrl"],
+		"3": ["LinkCUrl"]
+	},
+	"fields": {
+		"Title": "title",
+		"TitleDescription": "titleDescription",
+		"Headline": "headline",
+		"SubHeadline": "subheadline",
+		"StatementATitle": "statementATitle",
+		"StatementA": "statementA",
+		"StatementBTitle": "statementBTitle",
+		"StatementB": "statementB",
+		"ProofATitle": "proofATitle",
+		"ProofA": "proofA",
+		"ProofB": "proofB",
+		"ProofBTitle": "proofBTitle",
+		"ProofC": "proofC",
+		"ProofCTitle": "proofCTitle",
+		"ContradictionHandlerTitle": "contradictionHandlerTitle",
+		"ContradictionHandler": "contradictionHandler",
+		"ButtonA": "buttonA",
+		"ButtonB": "buttonB",
+		"VideoA": "videoA",
+		"VideoATitle": "videoATitle",
+		"VideoADescription": "videoADescription",
+		"VideoB": "videoB",
+		"VideoBTitle": "videoBTitle",
+		"VideoBDescription": "videoBDescription",
+		"Audio": "audio",
+		"AudioTitle": "audioTitle",
+		"AudioDescription": "audioDescription",
+		"DataA": "dataA",
+		"DataATitle": "dataATitle",
+		"DataAUrl": "dataAUrl",
+		"DataB": "dataB",
+		"DataBTitle": "dataBTitle",
+		"DataBUrl": "dataBUrl",
+		"DataC": "dataC",
+		"DataCTitle": "dataCTitle",
+		"DataCUrl": "dataCUrl",
+		"DataD": "dataD",
+		"DataDTitle": "dataDTitle",
+		"DataDUrl": "dataDUrl",
+		"Quote": "quote",
+		"SummaryTitle": "summaryTitle",
+		"Summary": "summary",
+		"Address": "address",
+		"Pricing": "pricing",
+		"PricingDescription": "pricingDescription",
+		"PlanA": "planA",
+		"PlanADescription": "planADescription",
+		"PlanADetails": "planADetails",
+		"PlanAUrl": "planAUrl",
+		"PlanABtn": "planABtn",
+		"AS": "AS",
+		"AP": "AP",
+		"AM": "AM",
+		"PlanB": "planB",
+		"PlanBDescription": "planBDescription",
+		"PlanBDetails": "planBDetails",
+		"PlanBUrl": "planBUrl",
+		"PlanBBtn": "planBBtn",
+		"BS": "BS",
+		"BP": "BP",
+		"BM": "BM",
+		"About": "about",
+		"AboutDescription": "aboutdescription",
+		"Contact": "contact",
+		"Phone": "phone",
+		"CTA": "cta",
+		"CTAUrl": "ctaurl",
+		"LinkAUrl": "linkaurl",
+		"LinkBUrl": "linkburl",
+		"LinkCUrl": "linkcurl",
+		"LinkABtn": "linkabtn",
+		"LinkBBtn": "linkbbtn",
+		"LinkCBtn": "linkcbtn"
+	}
+}`
+
+func init() {
+	RegisterSchema(mustParseDefinition(shortSchema))
+}
@@ -0,0 +1,153 @@
+package linker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Definition describes a page family. The content json of a page is loaded as a map so
+// every key in it can be used in template.html, Fields only gives template names to content
+// keys (Title -> title) and guarantees they render empty when the content leaves them out
+type Definition struct {
+	Name   string            `json:"name"`
+	Fields map[string]string `json:"fields,omitempty"`
+	// Ports lists the template names of the link slots of each output port, the
+	// connections of a port fill its slots in order
+	Ports map[string][]string `json:"ports,omitempty"`
+	// Links is shorthand for the slots of port 0
+	Links []string `json:"links,omitempty"`
+	// HTML names the fields trusted to hold html, every other value is escaped
+	HTML []string `json:"html,omitempty"`
+}
+
+// schemas is the registry of page families, pages render concurrently while a caller may
+// load definitions
+var (
+	schemasMu sync.RWMutex
+	schemas   = map[string]*Definition{}
+)
+
+// ParseDefinition decodes a json schema definition
+func ParseDefinition(r io.Reader) (*Definition, error) {
+	var def Definition
+	if err := json.NewDecoder(r).Decode(&def); err != nil {
+		return nil, fmt.Errorf("converting schema definition json %v", err)
+	}
+	if def.Name == "" {
+		return nil, errors.New("schema definition has no name")
+	}
+	if len(def.Links) > 0 {
+		if def.Ports == nil {
+			def.Ports = map[string][]string{}
+		}
+		if _, ok := def.Ports["0"]; ok {
+			return nil, fmt.Errorf("schema definition %s declares both links and port 0", def.Name)
+		}
+		def.Ports["0"] = def.Links
+	}
+	return &def, nil
+}
+
+func mustParseDefinition(js string) *Definition {
+	def, err := ParseDefinition(strings.NewReader(js))
+	if err != nil {
+		panic(err)
+	}
+	return def
+}
+
+// RegisterSchema makes a page family available by name, a later definition with the
+// same name replaces the earlier one so template roots can override the built in families
+func RegisterSchema(def *Definition) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	schemas[def.Name] = def
+}
+
+// LoadSchemas registers every *.json definition found in dir
+func LoadSchemas(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		def, err := ParseDefinition(strings.NewReader(string(data)))
+		if err != nil {
+			return fmt.Errorf("%s %v", name, err)
+		}
+		RegisterSchema(def)
+	}
+	return nil
+}
+
+// LookupSchema returns the named page family
+func LookupSchema(name string) (*Definition, bool) {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	def, ok := schemas[name]
+	return def, ok
+}
+
+// SchemaNames lists the registered page families
+func SchemaNames() []string {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Slot returns the template name for the n'th connection of an output port
+func (d *Definition) Slot(port string, n int) (string, error) {
+	slots, ok := d.Ports[port]
+	if !ok {
+		return "", fmt.Errorf("schema %s has no link slots for output port %s", d.Name, port)
+	}
+	if n >= len(slots) {
+		return "", fmt.Errorf("schema %s has %d link slots for output port %s, connection %d has none", d.Name, len(slots), port, n)
+	}
+	return slots[n], nil
+}
+
+// Data builds the template data of a page from its content, the declared fields and link
+// slots default to an empty string and resolved links replace their slot's content value
+func (d *Definition) Data(content map[string]interface{}, links map[string]string) map[string]interface{} {
+	data := make(map[string]interface{}, len(content)+len(d.Fields))
+	for key, value := range content {
+		data[key] = value
+	}
+	for name, key := range d.Fields {
+		if value, ok := content[key]; ok {
+			data[name] = value
+		} else if _, ok := data[name]; !ok {
+			data[name] = ""
+		}
+	}
+	for _, slots := range d.Ports {
+		for _, slot := range slots {
+			if _, ok := data[slot]; !ok {
+				data[slot] = ""
+			}
+		}
+	}
+	for slot, url := range links {
+		if url != "" {
+			data[slot] = url
+		}
+	}
+	return data
+}
@@ -0,0 +1,37 @@
+package linker
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// the registry is read by pages rendering concurrently while definitions are loaded, run with -race
+func TestSchemaRegistryConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	def := `{"name": "concurrent", "links": ["CTAUrl"], "fields": {"Title": "title"}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "concurrent.json"), []byte(def), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := LoadSchemas(dir); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			LookupSchema("short")
+			SchemaNames()
+		}()
+	}
+	wg.Wait()
+	if _, ok := LookupSchema("concurrent"); !ok {
+		t.Fatal("concurrent schema not registered")
+	}
+}
@@ -0,0 +1,183 @@
+package linker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// the sources a tracking parameter can take its value from
+const (
+	// SourceComponent is the Name of the flow component with Reference Ref
+	SourceComponent = "component"
+	// SourcePage is the field Ref of the linking page (pagename, pagetype, output, reference, name, id)
+	SourcePage = "page"
+	// SourceConstant is Value itself
+	SourceConstant = "constant"
+	// SourceEnv is the environment variable Ref
+	SourceEnv = "env"
+)
+
+var pageFields = map[string]bool{"pagename": true, "pagetype": true, "output": true, "reference": true, "name": true, "id": true}
+
+// TrackingParam declares one query parameter appended to the links, Name is the parameter
+// as it appears in the url so a source can be renamed (affiliate -> aff_id)
+type TrackingParam struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Ref    string `json:"ref,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Lower  bool   `json:"lower,omitempty"`
+	// SkipEmpty leaves the parameter out when its value is empty
+	SkipEmpty bool `json:"skipEmpty,omitempty"`
+	// Omit drops the parameter, only meaningful in an override
+	Omit bool `json:"omit,omitempty"`
+}
+
+// TrackingProfile is the set of parameters appended to every link. Overrides are keyed by
+// "<page reference>.<slot>" or "<slot>" and replace (by Name), drop or add parameters for
+// the matching links
+type TrackingProfile struct {
+	Params    []TrackingParam            `json:"params"`
+	Overrides map[string][]TrackingParam `json:"overrides,omitempty"`
+}
+
+// DefaultTracking is the profile used when neither the options nor the flow declare one
+var DefaultTracking = &TrackingProfile{
+	Params: []TrackingParam{
+		{Name: "utm_campaign", Source: SourceComponent, Ref: "utm_campaign"},
+		{Name: "utm_source", Source: SourcePage, Ref: "reference", Lower: true},
+		{Name: "utm_content", Source: SourceComponent, Ref: "utm_content"},
+		{Name: "utm_affiliate", Source: SourceComponent, Ref: "affiliate"},
+		{Name: "utm_medium", Source: SourceComponent, Ref: "utm_medium"},
+		{Name: "pagename", Source: SourcePage, Ref: "pagename"},
+		{Name: "pagetype", Source: SourcePage, Ref: "pagetype"},
+	},
+}
+
+// ParseTracking decodes a json tracking profile
+func ParseTracking(r io.Reader) (*TrackingProfile, error) {
+	var profile TrackingProfile
+	if err := json.NewDecoder(r).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("converting tracking profile json %v", err)
+	}
+	if err := profile.check(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// readsEnv reports whether a parameter or override of the profile has the env source
+func (t *TrackingProfile) readsEnv() bool {
+	for _, p := range t.Params {
+		if p.Source == SourceEnv {
+			return true
+		}
+	}
+	for _, params := range t.Overrides {
+		for _, p := range params {
+			if p.Source == SourceEnv {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *TrackingProfile) check() error {
+	all := append([]TrackingParam{}, t.Params...)
+	for _, params := range t.Overrides {
+		all = append(all, params...)
+	}
+	for _, p := range all {
+		if p.Name == "" {
+			return fmt.Errorf("tracking parameter without a name")
+		}
+		switch p.Source {
+		case SourceComponent, SourcePage, SourceEnv:
+			if p.Ref == "" && !p.Omit {
+				return fmt.Errorf("tracking parameter %s needs a ref for source %s", p.Name, p.Source)
+			}
+			if p.Source == SourcePage && !pageFields[p.Ref] && !p.Omit {
+				return fmt.Errorf("tracking parameter %s has unknown page field %s", p.Name, p.Ref)
+			}
+		case SourceConstant:
+		case "":
+			if !p.Omit {
+				return fmt.Errorf("tracking parameter %s has no source", p.Name)
+			}
+		default:
+			return fmt.Errorf("tracking parameter %s has unknown source %s", p.Name, p.Source)
+		}
+	}
+	return nil
+}
+
+// params resolves the query parameters of the link in slot of page
+func (t *TrackingProfile) params(vars map[string]string, page *Page, slot string) []Param {
+	list := t.Params
+	override, ok := t.Overrides[page.Component.Reference+"."+slot]
+	if !ok {
+		override, ok = t.Overrides[slot]
+	}
+	if ok {
+		list = append([]TrackingParam{}, t.Params...)
+		for _, o := range override {
+			replaced := false
+			for i := range list {
+				if list[i].Name == o.Name {
+					list[i] = o
+					replaced = true
+				}
+			}
+			if !replaced {
+				list = append(list, o)
+			}
+		}
+	}
+
+	var params []Param
+	for _, p := range list {
+		if p.Omit {
+			continue
+		}
+		value := p.value(vars, page)
+		if p.Lower {
+			value = strings.ToLower(value)
+		}
+		if value == "" && p.SkipEmpty {
+			continue
+		}
+		params = append(params, Param{Key: p.Name, Value: value})
+	}
+	return params
+}
+
+func (p TrackingParam) value(vars map[string]string, page *Page) string {
+	switch p.Source {
+	case SourceComponent:
+		return vars[p.Ref]
+	case SourceEnv:
+		return os.Getenv(p.Ref)
+	case SourceConstant:
+		return p.Value
+	case SourcePage:
+		switch p.Ref {
+		case "pagename":
+			return page.Files.Pagename
+		case "pagetype":
+			return page.Files.Pagetype
+		case "output":
+			return page.Files.Output
+		case "reference":
+			return page.Component.Reference
+		case "name":
+			return page.Component.Name
+		case "id":
+			return page.Component.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package linker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTrackingParams(t *testing.T) {
+	t.Setenv("URL_LINKER_TEST_AFF", "env-aff")
+	vars := map[string]string{"utm_campaign": "spring", "affiliate": "aff1", "utm_content": ""}
+	page := &Page{
+		Component: Component{ID: "p1", Name: "landing", Reference: "Landing"},
+		Files:     FileDetails{Output: "index.html", Pagename: "landing", Pagetype: "sales"},
+	}
+	base := []TrackingParam{
+		{Name: "utm_campaign", Source: SourceComponent, Ref: "utm_campaign"},
+		{Name: "utm_source", Source: SourcePage, Ref: "reference", Lower: true},
+		{Name: "utm_content", Source: SourceComponent, Ref: "utm_content"},
+	}
+	tests := []struct {
+		name      string
+		params    []TrackingParam
+		overrides map[string][]TrackingParam
+		slot      string
+		want      []Param
+	}{
+		{"base profile", base, nil, "CTAUrl",
+			[]Param{{"utm_campaign", "spring"}, {"utm_source", "landing"}, {"utm_content", ""}}},
+		{"renamed source", []TrackingParam{{Name: "aff_id", Source: SourceComponent, Ref: "affiliate"}}, nil, "CTAUrl",
+			[]Param{{"aff_id", "aff1"}}},
+		{"every source", []TrackingParam{
+			{Name: "c", Source: SourceConstant, Value: "Fixed"},
+			{Name: "e", Source: SourceEnv, Ref: "URL_LINKER_TEST_AFF"},
+			{Name: "o", Source: SourcePage, Ref: "output"},
+			{Name: "i", Source: SourcePage, Ref: "id"},
+			{Name: "t", Source: SourcePage, Ref: "pagetype"},
+			{Name: "missing", Source: SourceComponent, Ref: "nope"},
+		}, nil, "CTAUrl",
+			[]Param{{"c", "Fixed"}, {"e", "env-aff"}, {"o", "index.html"}, {"i", "p1"}, {"t", "sales"}, {"missing", ""}}},
+		{"lower", []TrackingParam{{Name: "c", Source: SourceConstant, Value: "MiXeD", Lower: true}}, nil, "CTAUrl",
+			[]Param{{"c", "mixed"}}},
+		{"skip empty", []TrackingParam{
+			{Name: "utm_campaign", Source: SourceComponent, Ref: "utm_campaign", SkipEmpty: true},
+			{Name: "utm_content", Source: SourceComponent, Ref: "utm_content", SkipEmpty: true},
+			{Name: "missing", Source: SourceComponent, Ref: "nope", SkipEmpty: true},
+		}, nil, "CTAUrl",
+			[]Param{{"utm_campaign", "spring"}}},
+		{"slot override replaces by name and adds", base, map[string][]TrackingParam{
+			"CTAUrl": {{Name: "utm_campaign", Source: SourceConstant, Value: "cta"}, {Name: "extra", Source: SourceConstant, Value: "x"}},
+		}, "CTAUrl",
+			[]Param{{"utm_campaign", "cta"}, {"utm_source", "landing"}, {"utm_content", ""}, {"extra", "x"}}},
+		{"override of another slot", base, map[string][]TrackingParam{
+			"DeclineUrl": {{Name: "utm_campaign", Source: SourceConstant, Value: "decline"}},
+		}, "CTAUrl",
+			[]Param{{"utm_campaign", "spring"}, {"utm_source", "landing"}, {"utm_content", ""}}},
+		{"page slot override wins over slot override", base, map[string][]TrackingParam{
+			"CTAUrl":         {{Name: "utm_campaign", Source: SourceConstant, Value: "slot"}},
+			"Landing.CTAUrl": {{Name: "utm_campaign", Source: SourceConstant, Value: "page"}},
+		}, "CTAUrl",
+			[]Param{{"utm_campaign", "page"}, {"utm_source", "landing"}, {"utm_content", ""}}},
+		{"page slot override of another page", base, map[string][]TrackingParam{
+			"CTAUrl":       {{Name: "utm_campaign", Source: SourceConstant, Value: "slot"}},
+			"Offer.CTAUrl": {{Name: "utm_campaign", Source: SourceConstant, Value: "page"}},
+		}, "CTAUrl",
+			[]Param{{"utm_campaign", "slot"}, {"utm_source", "landing"}, {"utm_content", ""}}},
+		{"omit", base, map[string][]TrackingParam{
+			"CTAUrl": {{Name: "utm_source", Omit: true}, {Name: "utm_content", Omit: true}},
+		}, "CTAUrl",
+			[]Param{{"utm_campaign", "spring"}}},
+		{"override renames by replacing the source", base, map[string][]TrackingParam{
+			"CTAUrl": {{Name: "utm_source", Omit: true}, {Name: "src", Source: SourcePage, Ref: "reference", Lower: true}},
+		}, "CTAUrl",
+			[]Param{{"utm_campaign", "spring"}, {"utm_content", ""}, {"src", "landing"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := &TrackingProfile{Params: tt.params, Overrides: tt.overrides}
+			if err := profile.check(); err != nil {
+				t.Fatal(err)
+			}
+			got := profile.params(vars, page, tt.slot)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params\n got %v\nwant %v", got, tt.want)
+			}
+			if len(tt.params) > 0 && len(profile.Params) != len(tt.params) {
+				t.Errorf("the override changed the profile params %v", profile.Params)
+			}
+		})
+	}
+}
+
+func TestTrackingCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		err     string
+	}{
+		{"valid", `{"params":[{"name":"a","source":"constant","value":"x"}],"overrides":{"CTAUrl":[{"name":"a","omit":true}]}}`, ""},
+		{"no name", `{"params":[{"source":"constant"}]}`, "without a name"},
+		{"no source", `{"params":[{"name":"a"}]}`, "has no source"},
+		{"unknown source", `{"params":[{"name":"a","source":"cookie"}]}`, "unknown source"},
+		{"no ref", `{"params":[{"name":"a","source":"component"}]}`, "needs a ref"},
+		{"unknown page field", `{"params":[{"name":"a","source":"page","ref":"title"}]}`, "unknown page field"},
+		{"bad override", `{"params":[],"overrides":{"CTAUrl":[{"name":"a","source":"env"}]}}`, "needs a ref"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTracking(strings.NewReader(tt.profile))
+			if tt.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+// trackingFlow links a landing page to an offer page with the tracking profile in its variables
+func trackingFlow(profile string) *Flow {
+	return &Flow{
+		Variables: `{"tracking":` + profile + `}`,
+		Components: []Component{
+			{ID: "v1", Component: "comment", Name: "https://example.com/", Reference: "base_url"},
+			{ID: "p1", Component: "page", Name: "landing", Reference: "Landing",
+				Connections: Connection{"0": {{Index: "0", ID: "p2"}}},
+				Options:     OptionDetails{Template: `{"output":"index.html","content":"content.json","pagename":"landing","pagetype":"origin"}`}},
+			{ID: "p2", Component: "page", Name: "offer", Reference: "Offer",
+				Options: OptionDetails{Template: `{"output":"offer.html","content":"content.json","pagename":"offer","pagetype":"sales"}`}},
+		},
+	}
+}
+
+func TestTrackingDenyFlowEnv(t *testing.T) {
+	t.Setenv("URL_LINKER_TEST_SECRET", "s3cret")
+	envProfile := `{"params":[{"name":"k","source":"env","ref":"URL_LINKER_TEST_SECRET"}]}`
+	envOverride := `{"params":[],"overrides":{"CTAUrl":[{"name":"k","source":"env","ref":"URL_LINKER_TEST_SECRET"}]}}`
+	for _, profile := range []string{envProfile, envOverride} {
+		if _, err := Link(trackingFlow(profile), Options{DenyFlowEnv: true}); err == nil || !strings.Contains(err.Error(), "environment") {
+			t.Errorf("flow profile %s linked with DenyFlowEnv, error %v", profile, err)
+		}
+	}
+
+	// the operator's profile may read the environment, the flow's is then ignored
+	operator, err := ParseTracking(strings.NewReader(envProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Link(trackingFlow(envProfile), Options{DenyFlowEnv: true, Tracking: operator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link := result.Pages[0].Links["CTAUrl"]; !strings.Contains(link, "k=s3cret") {
+		t.Errorf("link %s does not carry the operator's env parameter", link)
+	}
+
+	// without DenyFlowEnv the flow's profile reads it
+	result, err = Link(trackingFlow(envProfile), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link := result.Pages[0].Links["CTAUrl"]; !strings.Contains(link, "k=s3cret") {
+		t.Errorf("link %s does not carry the env parameter", link)
+	}
+}
@@ -0,0 +1,137 @@
+package linker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Component struct {
+	ID          string        `json:"id"`
+	Component   string        `json:"component"`
+	Tab         string        `json:"tab"`
+	Name        string        `json:"name"`
+	Reference   string        `json:"reference,omitempty"`
+	X           int           `json:"x"`
+	Y           int           `json:"y"`
+	Connections Connection    `json:"connections,omitempty"`
+	Options     OptionDetails `json:"options,omitempty"`
+}
+
+// Connection holds the links of every output port of a component keyed by port number
+type Connection map[string][]Wire
+
+// Wire links an output port to the input Index of the component ID, when the page
+// declares named link slots the Label (or the Index without one) selects the slot
+type Wire struct {
+	Index string `json:"index"`
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// SlotName is the name of the link slot the wire selects
+func (w Wire) SlotName() string {
+	if w.Label != "" {
+		return w.Label
+	}
+	return w.Index
+}
+
+// Ports returns the output ports that have links in numeric order
+func (c Connection) Ports() []string {
+	var ports []string
+	for port := range c {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		a, errA := strconv.Atoi(ports[i])
+		b, errB := strconv.Atoi(ports[j])
+		if errA != nil || errB != nil {
+			return ports[i] < ports[j]
+		}
+		return a < b
+	})
+	return ports
+}
+
+type OptionDetails struct {
+	Code       string        `json:"code,omitempty"`
+	Outputs    int           `json:"outputs,omitempty"`
+	Filename   string        `json:"filename,omitempty"`
+	Append     bool          `json:"append,omitempty"`
+	Delimiter  string        `json:"delimiter,omitempty"`
+	Template   string        `json:"template,omitempty"`
+	Layout     bool          `json:"layout,omitempty"`
+	Name       string        `json:"name,omitempty"`
+	Arg        []interface{} `json:"arg,omitempty"`
+	Convert    string        `json:"convert,omitempty"`
+	Timeout    int           `json:"timeout,omitempty"`
+	Type       string        `json:"type,omitempty"`
+	Repository bool          `json:"repository,omitempty"`
+	Enabled    bool          `json:"enabled,omitempty"`
+	Parser     string        `json:"parser,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	Method     string        `json:"method,omitempty"`
+	Stringify  string        `json:"stringify,omitempty"`
+	Props      []string      `json:"props,omitempty"`
+	ID         bool          `json:"id,omitempty"`
+	Fn         string        `json:"fn,omitempty"`
+}
+
+// Tab is a designer tab, components name theirs by ID
+type Tab struct {
+	Name   string `json:"name"`
+	Linker string `json:"linker"`
+	ID     string `json:"id"`
+	Index  int    `json:"index"`
+}
+
+type Flow struct {
+	Tabs       []Tab       `json:"tabs"`
+	Components []Component `json:"components,omitempty"`
+	Disabledio struct {
+		Input  []interface{} `json:"input"`
+		Output []interface{} `json:"output"`
+	} `json:"disabledio"`
+	State struct {
+		Text  string `json:"text"`
+		Color string `json:"color"`
+	} `json:"state"`
+	Color     string    `json:"color"`
+	Notes     string    `json:"notes"`
+	Variables string    `json:"variables"`
+	Panel     string    `json:"panel"`
+	URL       string    `json:"url"`
+	Created   time.Time `json:"created"`
+}
+
+// FileDetails is the page descriptor embedded as json in a component's Options.Template
+type FileDetails struct {
+	Output   string `json:"output"`
+	Content  string `json:"content"`
+	Pagename string `json:"pagename"`
+	Pagetype string `json:"pagetype"`
+	Schema   string `json:"schema,omitempty"`
+	// Links declares the page's named link slots, slot name -> template variable
+	// (the slot name itself when empty), they replace the schema's positional port slots
+	Links map[string]string `json:"links,omitempty"`
+	// HTML names the page's fields trusted to hold html, on top of the schema's
+	HTML []string `json:"html,omitempty"`
+	// LinkMode is how the page emits its links, it takes precedence over the flow's
+	LinkMode string `json:"linkMode,omitempty"`
+	// CSP lists the sources the page allows on top of the base policy, per directive
+	CSP map[string][]string `json:"csp,omitempty"`
+}
+
+// Slot returns the template variable of a named link slot
+func (f FileDetails) Slot(name string) (string, error) {
+	slot, ok := f.Links[name]
+	if !ok {
+		return "", fmt.Errorf("connection targets undeclared link slot %q", name)
+	}
+	if slot == "" {
+		slot = name
+	}
+	return slot, nil
+}
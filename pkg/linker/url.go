@@ -0,0 +1,49 @@
+package linker
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Param is a query parameter, they are kept in a slice so links always encode in the same order
+type Param struct {
+	Key   string
+	Value string
+}
+
+// BuildURL joins the path elements onto base and appends the params to base's own query.
+// Parameters of base with the same key as one of params are replaced, the others keep their
+// position in front of params
+func BuildURL(base *url.URL, elems []string, params []Param) string {
+	u := *base
+	u.Path = path.Join(append([]string{base.Path}, elems...)...)
+	if u.Host != "" && !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	u.RawPath = ""
+
+	set := map[string]bool{}
+	for _, p := range params {
+		set[p.Key] = true
+	}
+	var query []string
+	for _, pair := range strings.Split(base.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key := pair
+		if i := strings.Index(pair, "="); i >= 0 {
+			key = pair[:i]
+		}
+		if k, err := url.QueryUnescape(key); err == nil && set[k] {
+			continue
+		}
+		query = append(query, pair)
+	}
+	for _, p := range params {
+		query = append(query, url.QueryEscape(p.Key)+"="+url.QueryEscape(p.Value))
+	}
+	u.RawQuery = strings.Join(query, "&")
+	return u.String()
+}
@@ -0,0 +1,72 @@
+package linker
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildURL(t *testing.T) {
+	params := []Param{{"utm_campaign", "spring"}, {"utm_source", "landing"}}
+	tests := []struct {
+		name   string
+		base   string
+		elems  []string
+		params []Param
+		want   string
+	}{
+		{"trailing slash", "https://example.com/", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"no trailing slash", "https://example.com", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"base path", "https://example.com/funnel", []string{"offer", "index.html"}, params,
+			"https://example.com/funnel/offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"base path with trailing slash", "https://example.com/funnel/", []string{"offer", "index.html"}, params,
+			"https://example.com/funnel/offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"base query kept in front", "https://example.com/?ref=abc&lang=en", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?ref=abc&lang=en&utm_campaign=spring&utm_source=landing"},
+		{"base query key replaced by the profile", "https://example.com/?utm_campaign=old&ref=abc", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?ref=abc&utm_campaign=spring&utm_source=landing"},
+		{"base query key without value", "https://example.com/?debug&utm_source=old", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?debug&utm_campaign=spring&utm_source=landing"},
+		{"escaped base query key replaced", "https://example.com/?utm%5Fcampaign=old", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"space ampersand and hash in values", "https://example.com/", []string{"offer", "index.html"},
+			[]Param{{"utm_campaign", "spring sale & more #1"}, {"a=b", "c&d=e"}},
+			"https://example.com/offer/index.html?utm_campaign=spring+sale+%26+more+%231&a%3Db=c%26d%3De"},
+		{"non-ascii value", "https://example.com/", []string{"offer", "index.html"}, []Param{{"utm_source", "café ü"}},
+			"https://example.com/offer/index.html?utm_source=caf%C3%A9+%C3%BC"},
+		{"non-ascii and spaces in the path", "https://example.com/", []string{"my page", "ü.html"}, nil,
+			"https://example.com/my%20page/%C3%BC.html"},
+		{"empty value", "https://example.com/", []string{"offer", "index.html"}, []Param{{"utm_content", ""}},
+			"https://example.com/offer/index.html?utm_content="},
+		{"base fragment kept", "https://example.com/?ref=a#top", []string{"offer", "index.html"}, params,
+			"https://example.com/offer/index.html?ref=a&utm_campaign=spring&utm_source=landing#top"},
+		{"empty base", "", []string{"offer", "index.html"}, params,
+			"offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"host relative base", "/local/", []string{"offer", "index.html"}, params,
+			"/local/offer/index.html?utm_campaign=spring&utm_source=landing"},
+		{"no params", "https://example.com/?ref=abc", []string{"offer", "index.html"}, nil,
+			"https://example.com/offer/index.html?ref=abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := url.Parse(tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := BuildURL(base, tt.elems, tt.params); got != tt.want {
+				t.Errorf("BuildURL(%q, %v)\n got %s\nwant %s", tt.base, tt.elems, got, tt.want)
+			}
+			// params are read back with their original values
+			u, err := url.Parse(BuildURL(base, tt.elems, tt.params))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range tt.params {
+				if got := u.Query().Get(p.Key); got != p.Value {
+					t.Errorf("param %s is %q, want %q", p.Key, got, p.Value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package linker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// issue severities, only errors make a flow invalid
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// TerminalPagetypes are the pagetypes that may end a funnel without outgoing connections
+var TerminalPagetypes = []string{"thankyou", "confirmation", "terminal", "exit"}
+
+// Issue is a structural problem found in the funnel graph
+type Issue struct {
+	Severity  string
+	ID        string
+	Name      string
+	Reference string
+	Message   string
+}
+
+func (i Issue) String() string {
+	if i.ID == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("[%s %s] %s", i.Reference, i.Name, i.Message)
+}
+
+// HasErrors reports whether any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate builds the graph of page components and their connections and reports unreadable
+// descriptors, connections to unknown components, a missing or repeated origin page, pages
+// that can not be reached from the origin, cycles and dead ends without a terminal pagetype
+func Validate(flow *Flow) []Issue {
+	var issues []Issue
+	report := func(severity string, c *Component, format string, args ...interface{}) {
+		issue := Issue{Severity: severity, Message: fmt.Sprintf(format, args...)}
+		if c != nil {
+			issue.ID, issue.Name, issue.Reference = c.ID, c.Name, c.Reference
+		}
+		issues = append(issues, issue)
+	}
+
+	var pages []*Component
+	byID := map[string]*Component{}
+	files := map[string]FileDetails{}
+	for i := range flow.Components {
+		c := &flow.Components[i]
+		if c.Component == "comment" {
+			continue
+		}
+		if _, ok := byID[c.ID]; ok {
+			report(SeverityError, c, "duplicate component id %s", c.ID)
+			continue
+		}
+		pages = append(pages, c)
+		byID[c.ID] = c
+		var f FileDetails
+		if err := json.Unmarshal([]byte(c.Options.Template), &f); err != nil {
+			report(SeverityError, c, "converting embedded file data json %v", err)
+			continue
+		}
+		files[c.ID] = f
+	}
+
+	// edges only to known pages, dangling ones are reported once here and do not make the
+	// page a dead end as well
+	edges := map[string][]string{}
+	wired := map[string]bool{}
+	for _, c := range pages {
+		for _, port := range c.Connections.Ports() {
+			for _, wire := range c.Connections[port] {
+				wired[c.ID] = true
+				if _, ok := byID[wire.ID]; !ok {
+					report(SeverityError, c, "output %s connects to non-existent component %s", port, wire.ID)
+					continue
+				}
+				edges[c.ID] = append(edges[c.ID], wire.ID)
+			}
+		}
+	}
+
+	var origins []*Component
+	for _, c := range pages {
+		if files[c.ID].Pagetype == "origin" {
+			origins = append(origins, c)
+		}
+	}
+	switch {
+	case len(pages) == 0:
+		report(SeverityError, nil, "flow has no page components")
+		return issues
+	case len(origins) == 0:
+		report(SeverityError, nil, "flow has no origin page")
+	case len(origins) > 1:
+		for _, c := range origins {
+			report(SeverityError, c, "multiple origin pages, only one page may have pagetype origin")
+		}
+	}
+
+	// walk from the origin pages, anything not visited is unreachable
+	reached := map[string]bool{}
+	var queue []string
+	for _, c := range origins {
+		reached[c.ID] = true
+		queue = append(queue, c.ID)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, to := range edges[id] {
+			if !reached[to] {
+				reached[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	for _, c := range pages {
+		if len(origins) > 0 && !reached[c.ID] {
+			report(SeverityWarning, c, "page is not reachable from the origin page")
+		}
+		if f, ok := files[c.ID]; ok && !wired[c.ID] && !isTerminal(f.Pagetype) {
+			report(SeverityWarning, c, "dead end, page has no connections and pagetype %q is not terminal", f.Pagetype)
+		}
+	}
+
+	for _, cycle := range cycles(pages, edges) {
+		report(SeverityWarning, byID[cycle[0]], "page is part of a cycle %v", cycle)
+	}
+	return issues
+}
+
+func isTerminal(pagetype string) bool {
+	for _, t := range TerminalPagetypes {
+		if t == pagetype {
+			return true
+		}
+	}
+	return false
+}
+
+// cycles returns one path for every back edge found by a depth first walk of the pages
+func cycles(pages []*Component, edges map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	var found [][]string
+	state := map[string]int{}
+	var path []string
+	var walk func(id string)
+	walk = func(id string) {
+		state[id] = active
+		path = append(path, id)
+		for _, to := range edges[id] {
+			switch state[to] {
+			case unvisited:
+				walk(to)
+			case active:
+				for i := range path {
+					if path[i] == to {
+						found = append(found, append([]string{}, path[i:]...))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+	for _, c := range pages {
+		if state[c.ID] == unvisited {
+			walk(c.ID)
+		}
+	}
+	return found
+}
@@ -0,0 +1,81 @@
+package linker
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// validatePage returns a page component of pagetype wired to the ids on port 0
+func validatePage(id string, pagetype string, to ...string) Component {
+	c := Component{ID: id, Component: "page", Name: "page" + id, Reference: "Page" + id,
+		Options: OptionDetails{Template: fmt.Sprintf(`{"output":"index.html","content":"content.json","pagename":"%s","pagetype":"%s"}`, id, pagetype)}}
+	if len(to) > 0 {
+		c.Connections = Connection{"0": nil}
+		for _, id := range to {
+			c.Connections["0"] = append(c.Connections["0"], Wire{Index: "0", ID: id})
+		}
+	}
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	comment := Component{ID: "v1", Component: "comment", Name: "https://example.com/", Reference: "base_url"}
+	tests := []struct {
+		name       string
+		components []Component
+		// want lists the issues as "<severity> <id> <message>"
+		want []string
+	}{
+		{"valid funnel", []Component{comment, validatePage("a", "origin", "b"), validatePage("b", "sales", "c"), validatePage("c", "thankyou")},
+			nil},
+		{"no pages", []Component{comment},
+			[]string{"error  flow has no page components"}},
+		{"missing origin", []Component{validatePage("a", "sales", "b"), validatePage("b", "thankyou")},
+			[]string{"error  flow has no origin page"}},
+		{"multiple origins", []Component{validatePage("a", "origin", "c"), validatePage("b", "origin", "c"), validatePage("c", "thankyou")},
+			[]string{
+				"error a multiple origin pages, only one page may have pagetype origin",
+				"error b multiple origin pages, only one page may have pagetype origin",
+			}},
+		{"duplicate id", []Component{validatePage("a", "origin", "b"), validatePage("b", "thankyou"), validatePage("b", "thankyou")},
+			[]string{"error b duplicate component id b"}},
+		{"unreadable descriptor", []Component{validatePage("a", "origin", "b"),
+			{ID: "b", Component: "page", Name: "pageb", Reference: "Pageb", Options: OptionDetails{Template: `{`}}},
+			[]string{"error b converting embedded file data json unexpected end of JSON input"}},
+		{"dangling id", []Component{validatePage("a", "origin", "b", "x"), validatePage("b", "thankyou")},
+			[]string{"error a output 0 connects to non-existent component x"}},
+		{"only dangling wires is not a dead end", []Component{validatePage("a", "origin", "b"), validatePage("b", "sales", "x")},
+			[]string{"error b output 0 connects to non-existent component x"}},
+		{"dead end", []Component{validatePage("a", "origin", "b"), validatePage("b", "sales")},
+			[]string{`warning b dead end, page has no connections and pagetype "sales" is not terminal`}},
+		{"unreachable page", []Component{validatePage("a", "origin", "b"), validatePage("b", "thankyou"), validatePage("c", "thankyou")},
+			[]string{"warning c page is not reachable from the origin page"}},
+		{"cycle", []Component{validatePage("a", "origin", "b"), validatePage("b", "sales", "c"), validatePage("c", "upsell", "b", "d"), validatePage("d", "thankyou")},
+			[]string{"warning b page is part of a cycle [b c]"}},
+		{"self loop", []Component{validatePage("a", "origin", "a", "b"), validatePage("b", "thankyou")},
+			[]string{"warning a page is part of a cycle [a]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range Validate(&Flow{Components: tt.components}) {
+				got = append(got, fmt.Sprintf("%s %s %s", issue.Severity, issue.ID, issue.Message))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	if HasErrors([]Issue{{Severity: SeverityWarning}}) {
+		t.Error("warnings only reported as errors")
+	}
+	if !HasErrors([]Issue{{Severity: SeverityWarning}, {Severity: SeverityError}}) {
+		t.Error("error not reported")
+	}
+}
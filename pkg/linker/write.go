@@ -0,0 +1,80 @@
+package linker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to the name of the previous version of a page kept by WriteFile
+const BackupSuffix = ".bak"
+
+// DefaultMode is the file mode of a page written without one
+const DefaultMode os.FileMode = 0644
+
+// WriteOptions controls how WriteFile replaces a page
+type WriteOptions struct {
+	// Mode is the file mode of the page, DefaultMode (0644) when zero
+	Mode   os.FileMode
+	Backup bool
+}
+
+// WriteFile atomically replaces name with data, it is written to a hidden temp file in the
+// same directory and renamed over name so a reader sees either the old or the new page,
+// never a truncated one. With Backup the previous version is kept as name.bak
+func WriteFile(name string, data []byte, opts WriteOptions) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	// the temp file is gone after a successful rename, removing it again is harmless
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	mode := opts.Mode
+	if mode == 0 {
+		mode = DefaultMode
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if opts.Backup {
+		if err = backup(name); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// backup keeps the current version of name as name.bak, a hard link leaves the live page in
+// place until the rename, filesystems without links get a copy
+func backup(name string) error {
+	bak := name + BackupSuffix
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Link(name, bak)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return copyFile(name, bak, info)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+// preview serves the funnel from memory, every request reads the flow and renders the page
+// again so edits show up on refresh. The base_url of the flow is replaced by the preview
+// server's own so the links between pages stay local
+func preview(args []string) int {
+	var c config
+	fs := newFlagSet("preview", "[flow.json]")
+	c.register(fs)
+	c.registerOptions(fs)
+	addr := fs.String("addr", "127.0.0.1:8081", "address the preview server listens on")
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	logger := c.logger
+
+	// the schema definitions and the tracking profile are read once, the flow and the pages
+	// on every request
+	opts, err := c.options()
+	if err != nil {
+		logger.Error(err.Error())
+		return exitInput
+	}
+	p := &previewer{config: &c, opts: opts}
+	logger.Info(fmt.Sprintf("Previewing %s on http://%s/", c.flow, *addr))
+	if err = http.ListenAndServe(*addr, p); err != nil {
+		logger.Error(fmt.Sprintf("Serving preview %v", err))
+		return exitFailure
+	}
+	return exitOK
+}
+
+type previewer struct {
+	config *config
+	opts   linker.Options
+}
+
+// ServeHTTP renders the page at /<component name>/<output>, serves the other files of the
+// page folders as they are and lists the pages at /
+func (p *previewer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logger := p.config.logger
+	flow, err := readFlow(p.config.flow)
+	if err != nil {
+		logger.Error(err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	opts := p.opts
+	opts.BaseURL = "http://" + req.Host + "/"
+	result, err := linker.Link(flow, opts)
+	if err != nil {
+		logger.Error(err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(req.URL.Path), "/")
+	if name == "" {
+		p.index(w, result)
+		return
+	}
+	folders := map[string][]*linker.Page{}
+	for _, page := range result.Pages {
+		if filepath.ToSlash(page.Path()) == name {
+			data, err := linker.RenderPage(page, opts)
+			if err != nil {
+				logger.Error(err.Error())
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, warning := range pageWarnings(data, opts) {
+				logger.Warn(fmt.Sprintf("Page %s %s", name, warning))
+			}
+			if opts.CSP == linker.CSPHeaders {
+				w.Header().Set("Content-Security-Policy", linker.Policy(page, data))
+			}
+			logger.Debug(fmt.Sprintf("Rendered %s", name))
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write(data)
+			return
+		}
+		folders[page.Component.Name] = append(folders[page.Component.Name], page)
+	}
+
+	// the static assets of a page folder, the files --out would mirror and nothing else
+	parts := strings.SplitN(name, "/", 2)
+	asset := false
+	for _, page := range folders[parts[0]] {
+		asset = asset || (len(parts) == 2 && page.IsAsset(parts[1]))
+	}
+	if !asset {
+		http.NotFound(w, req)
+		return
+	}
+	file := filepath.Join(p.config.templates, filepath.FromSlash(name))
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		http.NotFound(w, req)
+		return
+	}
+	http.ServeFile(w, req, file)
+}
+
+var previewIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>url-linker preview</title></head>
+<body><h1>Funnel pages</h1><ul>
+{{ range . }}<li>{{ if .Err }}{{ .Component.Reference }} {{ .Component.Name }}: {{ .Err }}{{ else }}<a href="/{{ .Path }}">{{ .Component.Reference }} {{ .Component.Name }}</a> ({{ .Files.Pagetype }}){{ end }}</li>
+{{ end }}</ul></body></html>
+`))
+
+// index lists the pages of the flow, the pages that do not link show their error
+func (p *previewer) index(w http.ResponseWriter, result *linker.Result) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := previewIndex.Execute(w, result.Pages); err != nil {
+		p.config.logger.Error(fmt.Sprintf("Writing preview index %v", err))
+	}
+}
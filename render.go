@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+// renderFlags are the flags of the commands that build the pages
+type renderFlags struct {
+	out      string
+	separate bool
+	failFast bool
+	mode     modeFlag
+	backup   bool
+	jobs     int
+	force    bool
+	dryRun   bool
+}
+
+// register adds the build flags to fs
+func (r *renderFlags) register(fs *flag.FlagSet) {
+	r.mode = modeFlag(linker.DefaultMode)
+	fs.StringVar(&r.out, "out", "", "output directory mirroring the page tree with its static assets (default render in place)")
+	fs.BoolVar(&r.failFast, "fail-fast", false, "stop at the first page that fails")
+	fs.Var(&r.mode, "mode", "file mode of the written pages, in octal")
+	fs.BoolVar(&r.backup, "backup", false, "keep the previous version of each page as <output>.bak")
+	fs.IntVar(&r.jobs, "jobs", runtime.NumCPU(), "number of pages rendered and written in parallel")
+	fs.BoolVar(&r.force, "force", false, "rebuild every page, even those the build manifest shows unchanged")
+}
+
+// setup checks the build flags, without --out the pages are rendered in place
+func (r *renderFlags) setup(c *config) error {
+	if r.jobs < 1 {
+		return errors.New("--jobs must be at least 1")
+	}
+	// with a separate output directory the sources are never written to
+	r.separate = r.out != ""
+	if !r.separate {
+		r.out = c.templates
+	} else if same, _ := sameDir(r.out, c.templates); same {
+		return fmt.Errorf("--out %s is the template root, leave it out to render in place", r.out)
+	}
+	return nil
+}
+
+// render links the flow and writes every rendered page, it returns the exit code of the
+// most severe kind of error collected
+func render(args []string) int {
+	var c config
+	var r renderFlags
+	fs := newFlagSet("render", "[flow.json]")
+	c.register(fs)
+	c.registerOptions(fs)
+	r.register(fs)
+	fs.BoolVar(&r.dryRun, "dry-run", false, "render in memory and print a unified diff against the pages on disk instead of writing")
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	if err := r.setup(&c); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	o := loadAndBuild(&c, &r)
+	o.summary(c.logger)
+	return o.exitCode()
+}
+
+// outcome is a build, its report and the result of every page
+type outcome struct {
+	report
+	// result is nil when the flow could not be linked
+	result *linker.Result
+	// pages are in flow order, the pages never started are nil
+	pages []*pageResult
+}
+
+// loadAndBuild reads the flow named by the config and builds it
+func loadAndBuild(c *config, r *renderFlags) *outcome {
+	flow, opts, err := c.load()
+	if err != nil {
+		o := &outcome{}
+		o.add(err)
+		return o
+	}
+	return build(c, r, flow, opts)
+}
+
+// build links the flow and renders its pages
+func build(c *config, r *renderFlags, flow *linker.Flow, opts linker.Options) *outcome {
+	logger := c.logger
+	o := &outcome{}
+	result, err := linker.Link(flow, opts)
+	if err != nil {
+		o.add(err)
+		return o
+	}
+	o.result = result
+	logger.Debug(fmt.Sprintf("Variables %v", result.Variables))
+	if r.separate {
+		// the mirrored assets of a page would be copied into its own folder over and over
+		if err = outsidePages(r.out, c.templates, result.Pages); err != nil {
+			o.add(err)
+			return o
+		}
+	}
+
+	// pages whose inputs and output match the build manifest are skipped unless --force,
+	// the manifest still tells which sidecar headers files the linker wrote
+	manifestFile := filepath.Join(r.out, linker.ManifestFile)
+	manifest, err := linker.ReadManifest(manifestFile)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Ignoring build manifest %s %v", manifestFile, err))
+	}
+	if manifest == nil {
+		manifest = linker.NewManifest()
+	}
+
+	// links are resolved, the pages are rendered and written by a pool of workers and
+	// reported in flow order so the log reads the same whatever the number of jobs.
+	// A failing page is reported and skipped, the others still render unless --fail-fast
+	w := pageWriter{out: r.out, templates: c.templates, separate: r.separate, dryRun: r.dryRun, force: r.force,
+		opts: opts, write: linker.WriteOptions{Mode: os.FileMode(r.mode), Backup: r.backup}, manifest: manifest}
+	built := linker.NewManifest()
+	var dry dryRunCounts
+	o.pages = renderPages(result.Pages, r.jobs, r.failFast, w.process)
+	for i, res := range o.pages {
+		if res == nil {
+			// never started, what was built before is still there
+			path := result.Pages[i].Path()
+			if entry, ok := manifest.Pages[path]; ok {
+				built.Pages[path] = entry
+			}
+			continue
+		}
+		logger.Debug(fmt.Sprintf("Links %s %v", res.name, res.page.Links))
+		if res.err != nil {
+			o.add(res.err)
+			continue
+		}
+		for _, warning := range res.warnings {
+			logger.Warn(fmt.Sprintf("Page %s %s", res.name, warning))
+		}
+		if r.dryRun {
+			dry.add(res.status)
+			fmt.Print(res.diff)
+			continue
+		}
+		built.Pages[res.page.Path()] = res.entry
+		if res.status == statusUpToDate {
+			o.upToDate++
+			logger.Debug(fmt.Sprintf("Up to date %s", res.name))
+			continue
+		}
+		o.written++
+		logger.Info(fmt.Sprintf("Succesfully saved file %s", res.name))
+	}
+	if r.dryRun {
+		logger.Info(fmt.Sprintf("Dry run %d added %d changed %d unchanged, nothing written", dry.added, dry.changed, dry.unchanged))
+	} else if err = built.Write(manifestFile); err != nil {
+		o.add(&linker.Error{Name: linker.ManifestFile, Op: linker.OpWrite, Err: err})
+	}
+	return o
+}
+
+// pageWarnings lists what would break the rendered page in the browser
+func pageWarnings(data []byte, opts linker.Options) []string {
+	var warnings []string
+	if linker.MissingInjectParams(data) {
+		warnings = append(warnings, "has injectParams links but does not define injectParams, add {{ injectParamsScript }} to template.html or render with --inject-script auto")
+	}
+	if opts.CSP != linker.CSPOff && bytes.Contains(data, []byte("javascript:")) {
+		warnings = append(warnings, "has javascript: links the Content-Security-Policy blocks, render with --link-mode enhanced")
+	}
+	return warnings
+}
+
+// the status of a page, written or left up to date by a build, added, changed or
+// unchanged by a dry run
+const (
+	statusWritten = iota
+	statusUpToDate
+	statusAdded
+	statusChanged
+	statusUnchanged
+)
+
+// pageResult is the outcome of rendering and writing (or diffing) one page
+type pageResult struct {
+	page     *linker.Page
+	name     string
+	status   int
+	diff     string
+	entry    linker.ManifestEntry
+	warnings []string
+	err      error
+}
+
+// renderPages runs process for every page on a pool of jobs workers, the results are in
+// page order. After a failure with failFast no more pages are started, the results of the
+// pages never started are nil
+func renderPages(pages []*linker.Page, jobs int, failFast bool, process func(*linker.Page) *pageResult) []*pageResult {
+	results := make([]*pageResult, len(pages))
+	next := make(chan int)
+	var failed int32
+	var wg sync.WaitGroup
+	for n := 0; n < jobs; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = process(pages[i])
+				if results[i].err != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	for i := range pages {
+		if failFast && atomic.LoadInt32(&failed) == 1 {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// pageWriter renders a page and writes it under out, or diffs it against the page there
+type pageWriter struct {
+	out       string
+	templates string
+	separate  bool
+	dryRun    bool
+	force     bool
+	opts      linker.Options
+	write     linker.WriteOptions
+	// manifest is only read by the workers
+	manifest *linker.Manifest
+}
+
+func (w pageWriter) process(page *linker.Page) *pageResult {
+	res := &pageResult{page: page, name: filepath.Join(w.out, page.Path())}
+	src, err := linker.LoadPage(page, w.opts)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	if !within(w.out, res.name) {
+		res.err = linker.NewError(page.Component, linker.OpWrite, fmt.Errorf("%s is outside the output directory %s", res.name, w.out))
+		return res
+	}
+	if w.dryRun {
+		data, err := linker.RenderSource(page, src, w.opts)
+		if err != nil {
+			res.err = err
+			return res
+		}
+		res.warnings = pageWarnings(data, w.opts)
+		if res.status, res.diff, err = diff(res.name, data); err != nil {
+			res.err = linker.NewError(page.Component, linker.OpWrite, err)
+		}
+		return res
+	}
+
+	inputs := linker.InputHash(page, src, w.opts)
+	data, err := ioutil.ReadFile(res.name)
+	if err == nil && !w.force && w.manifest.UpToDate(page.Path(), inputs, data, w.write.Mode) {
+		res.status = statusUpToDate
+		res.entry = w.manifest.Pages[page.Path()]
+	} else {
+		if data, err = linker.RenderSource(page, src, w.opts); err != nil {
+			res.err = err
+			return res
+		}
+		if err = linker.WriteFile(res.name, data, w.write); err != nil {
+			res.err = linker.NewError(page.Component, linker.OpWrite, err)
+			return res
+		}
+		res.entry = linker.ManifestEntry{Inputs: inputs, Output: linker.Hash(data), Mode: w.write.Mode}
+	}
+	res.warnings = pageWarnings(data, w.opts)
+	res.entry.Headers, err = w.writeHeaders(page, res.name, data)
+	// assets are not part of the hash, CopyAssets only copies the ones that changed
+	if err == nil && w.separate {
+		err = linker.CopyAssets(page, w.templates, w.out)
+	}
+	if err != nil {
+		res.err = linker.NewError(page.Component, linker.OpWrite, err)
+	}
+	return res
+}
+
+// writeHeaders keeps the sidecar headers file of the page in line with the csp mode and
+// returns the hash recorded for it in the manifest. With --csp headers it holds the
+// Content-Security-Policy, otherwise the sidecar is removed if the linker wrote it and
+// nobody edited it since, a hand-written one is left alone
+func (w pageWriter) writeHeaders(page *linker.Page, name string, data []byte) (string, error) {
+	name += linker.HeadersSuffix
+	current, err := ioutil.ReadFile(name)
+	if w.opts.CSP != linker.CSPHeaders {
+		written := w.manifest.Pages[page.Path()].Headers
+		if err != nil || written == "" || linker.Hash(current) != written {
+			return "", nil
+		}
+		if err = os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return written, err
+		}
+		return "", nil
+	}
+	headers := []byte("Content-Security-Policy: " + linker.Policy(page, data) + "\n")
+	if err == nil && bytes.Equal(current, headers) && hasMode(name, w.write.Mode) {
+		return linker.Hash(headers), nil
+	}
+	// the sidecar is regenerated from the page, it is never backed up
+	if err = linker.WriteFile(name, headers, linker.WriteOptions{Mode: w.write.Mode}); err != nil {
+		return "", err
+	}
+	return linker.Hash(headers), nil
+}
+
+// dryRunCounts tallies the pages a dry run would add, change or leave alone
+type dryRunCounts struct {
+	added     int
+	changed   int
+	unchanged int
+}
+
+func (d *dryRunCounts) add(status int) {
+	switch status {
+	case statusAdded:
+		d.added++
+	case statusChanged:
+		d.changed++
+	default:
+		d.unchanged++
+	}
+}
+
+// diff returns the status and unified diff of the page on disk against its rendering
+func diff(name string, data []byte) (int, string, error) {
+	current, err := ioutil.ReadFile(name)
+	from := name
+	status := statusChanged
+	switch {
+	case os.IsNotExist(err):
+		status = statusAdded
+		from = "/dev/null"
+	case err != nil:
+		return 0, "", err
+	case bytes.Equal(current, data):
+		return statusUnchanged, "", nil
+	}
+	return status, linker.UnifiedDiff(current, data, from, name, 3), nil
+}
+
+// hasMode reports whether the file name has the permissions WriteFile gives it with mode
+func hasMode(name string, mode os.FileMode) bool {
+	if mode == 0 {
+		mode = linker.DefaultMode
+	}
+	info, err := os.Stat(name)
+	return err == nil && info.Mode().Perm() == mode.Perm()
+}
+
+// within reports whether name is inside the directory root
+func within(root string, name string) bool {
+	rel, err := filepath.Rel(root, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// outsidePages checks the output directory is not a page folder or inside one
+func outsidePages(out string, templates string, pages []*linker.Page) error {
+	absOut, err := filepath.Abs(out)
+	if err != nil {
+		return err
+	}
+	for _, page := range pages {
+		folder, err := filepath.Abs(filepath.Join(templates, page.Component.Name))
+		if err != nil {
+			return err
+		}
+		if within(folder, absOut) {
+			return fmt.Errorf("--out %s is inside the page folder %s, pick a directory outside the pages", out, folder)
+		}
+	}
+	return nil
+}
+
+// sameDir reports whether both paths name the same directory
+func sameDir(a string, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return absA == absB, nil
+}
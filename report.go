@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+	"github.com/microlib/simple"
+)
+
+// exit codes for failed runs, when several kinds of error occur the highest code wins
+const (
+	exitInput  = 3
+	exitRender = 4
+	exitIO     = 5
+)
+
+// report collects the errors of a run per component and turns them into a summary and exit code
+type report struct {
+	written  int
+	upToDate int
+	errs     []error
+}
+
+func (r *report) add(err error) {
+	r.errs = append(r.errs, err)
+}
+
+// kind returns the linker error kind, errors not tied to a component are input errors
+func kind(err error) string {
+	var e *linker.Error
+	if errors.As(err, &e) {
+		return e.Kind()
+	}
+	return linker.KindInput
+}
+
+// summary logs every collected error followed by the counts per kind
+func (r *report) summary(logger *simple.Logger) {
+	counts := map[string]int{}
+	for _, err := range r.errs {
+		counts[kind(err)]++
+	}
+	if len(r.errs) == 0 {
+		logger.Info(fmt.Sprintf("Summary %d pages written %d up to date, no errors", r.written, r.upToDate))
+		return
+	}
+	logger.Error(fmt.Sprintf("Summary %d pages written %d up to date, %d errors (input %d, render %d, io %d)",
+		r.written, r.upToDate, len(r.errs), counts[linker.KindInput], counts[linker.KindRender], counts[linker.KindIO]))
+	for _, err := range r.errs {
+		logger.Error(fmt.Sprintf("  %s %v", kind(err), err))
+	}
+}
+
+// exitCode is exitOK without errors, otherwise the code of the most severe kind (io > render > input)
+func (r *report) exitCode() int {
+	code := exitOK
+	for _, err := range r.errs {
+		c := exitInput
+		switch kind(err) {
+		case linker.KindRender:
+			c = exitRender
+		case linker.KindIO:
+			c = exitIO
+		}
+		if c > code {
+			code = c
+		}
+	}
+	return code
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+// maxFlowSize bounds the flow json accepted by the service
+const maxFlowSize = 10 << 20
+
+// serve runs the linker as an http service, POST /v1/link takes the designer flow json,
+// builds it against the template root like render and answers with the result of every page
+func serve(args []string) int {
+	var c config
+	var r renderFlags
+	fs := newFlagSet("serve", "")
+	c.register(fs)
+	c.registerOptions(fs)
+	r.register(fs)
+	addr := fs.String("addr", "127.0.0.1:8080", "address the service listens on")
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments %v, the flow is posted to the service", fs.Args())
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	if err := r.setup(&c); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	logger := c.logger
+	opts, err := c.options()
+	if err != nil {
+		logger.Error(err.Error())
+		return exitInput
+	}
+	// posted flows come from the network, only the operator's --tracking may read the environment
+	opts.DenyFlowEnv = true
+
+	s := &service{config: &c, flags: &r, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/link", s.link)
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	logger.Info(fmt.Sprintf("Serving POST /v1/link on %s, rendering to %s", *addr, r.out))
+	if err = srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(fmt.Sprintf("Serving %v", err))
+		return exitFailure
+	}
+	logger.Info("Stopped serving")
+	return exitOK
+}
+
+// service builds the posted flows one at a time, they share the output directory and its manifest
+type service struct {
+	mu     sync.Mutex
+	config *config
+	flags  *renderFlags
+	opts   linker.Options
+}
+
+// linkResponse is the json answer of POST /v1/link
+type linkResponse struct {
+	Written  int            `json:"written"`
+	UpToDate int            `json:"upToDate"`
+	Errors   []errorResult  `json:"errors,omitempty"`
+	Pages    []pageResponse `json:"pages,omitempty"`
+}
+
+// pageResponse is the result of one page, Status is written, up to date, failed or skipped
+// (not started after a failure with --fail-fast)
+type pageResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Reference string            `json:"reference,omitempty"`
+	Output    string            `json:"output"`
+	Links     map[string]string `json:"links,omitempty"`
+	Status    string            `json:"status"`
+	Warnings  []string          `json:"warnings,omitempty"`
+	Error     *errorResult      `json:"error,omitempty"`
+}
+
+type errorResult struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func newErrorResult(err error) *errorResult {
+	return &errorResult{Kind: kind(err), Message: err.Error()}
+}
+
+// link answers 200 when every page was built, 422 when some failed and 400 when the flow
+// could not be read or linked
+func (s *service) link(w http.ResponseWriter, req *http.Request) {
+	logger := s.config.logger
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flow, err := linker.Parse(http.MaxBytesReader(w, req.Body, maxFlowSize))
+	if err != nil {
+		logger.Error(err.Error())
+		writeJSON(w, http.StatusBadRequest, linkResponse{Errors: []errorResult{*newErrorResult(err)}})
+		return
+	}
+
+	s.mu.Lock()
+	o := build(s.config, s.flags, flow, s.opts)
+	s.mu.Unlock()
+	o.summary(logger)
+
+	resp := linkResponse{Written: o.written, UpToDate: o.upToDate}
+	for _, err := range o.errs {
+		resp.Errors = append(resp.Errors, *newErrorResult(err))
+	}
+	if o.result == nil {
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+	for i, page := range o.result.Pages {
+		p := pageResponse{
+			ID:        page.Component.ID,
+			Name:      page.Component.Name,
+			Reference: page.Component.Reference,
+			Output:    page.Path(),
+			Links:     page.Links,
+			Status:    "skipped",
+		}
+		if res := o.pages[i]; res != nil {
+			switch {
+			case res.err != nil:
+				p.Status = "failed"
+				p.Error = newErrorResult(res.err)
+			case res.status == statusUpToDate:
+				p.Status = "up to date"
+			default:
+				p.Status = "written"
+			}
+			p.Warnings = res.warnings
+		}
+		resp.Pages = append(resp.Pages, p)
+	}
+	status := http.StatusOK
+	if len(o.errs) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
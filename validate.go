@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+// validate reports the structural problems of the funnel graph, it returns the exit code
+// which is non zero when there are errors (warnings alone pass)
+func validate(args []string) int {
+	var c config
+	fs := newFlagSet("validate", "[flow.json]")
+	c.register(fs)
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	logger := c.logger
+
+	flow, err := readFlow(c.flow)
+	if err != nil {
+		logger.Error(err.Error())
+		return exitInput
+	}
+
+	issues := linker.Validate(flow)
+	var errors, warnings int
+	for _, issue := range issues {
+		if issue.Severity == linker.SeverityError {
+			errors++
+			logger.Error(issue.String())
+		} else {
+			warnings++
+			logger.Warn(issue.String())
+		}
+	}
+	logger.Info(fmt.Sprintf("Validated %s %d errors %d warnings", c.flow, errors, warnings))
+	if linker.HasErrors(issues) {
+		return exitInput
+	}
+	return exitOK
+}
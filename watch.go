@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/luigizuccarelli/golang-url-linker/pkg/linker"
+)
+
+// watch renders the flow, then polls the flow and the page folders and rebuilds after every
+// change until interrupted. The build manifest skips the pages whose inputs did not change,
+// so only the edited pages and the pages linking to a page whose url changed are rendered
+func watch(args []string) int {
+	var c config
+	var r renderFlags
+	fs := newFlagSet("watch", "[flow.json]")
+	c.register(fs)
+	c.registerOptions(fs)
+	r.register(fs)
+	interval := fs.Duration("interval", 500*time.Millisecond, "how often the sources are checked for changes")
+	if ok, code := parse(fs, args); !ok {
+		return code
+	}
+	if err := c.setup(fs); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	if err := r.setup(&c); err != nil {
+		return usageError(fs, "%v", err)
+	}
+	if *interval <= 0 {
+		return usageError(fs, "--interval must be positive")
+	}
+	logger := c.logger
+
+	var pages []*linker.Page
+	rebuild := func() {
+		o := loadAndBuild(&c, &r)
+		o.summary(logger)
+		// a flow that no longer links keeps the folders of the last one watched
+		if o.result != nil {
+			pages = o.result.Pages
+		}
+	}
+	rebuild()
+	state := snapshot(&c, pages)
+	logger.Info(fmt.Sprintf("Watching %s and %d page folders, press Ctrl+C to stop", c.flow, len(folders(pages))))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			logger.Info("Stopped watching")
+			return exitOK
+		case <-ticker.C:
+		}
+		next := snapshot(&c, pages)
+		changed := state.changes(next)
+		if len(changed) == 0 {
+			continue
+		}
+		logger.Info(fmt.Sprintf("Changed %s", strings.Join(changed, ", ")))
+		before := layout(pages)
+		rebuild()
+		state = next
+		// a page added or renamed in the flow changes what is watched, take it in without
+		// reporting its folder or its old output as a change
+		if layout(pages) != before {
+			state = snapshot(&c, pages)
+		}
+	}
+}
+
+// fileState is what a change of a watched file is detected by
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// sources maps every watched file to its state
+type sources map[string]fileState
+
+// changes lists the files added, removed or modified in next, sorted
+func (s sources) changes(next sources) []string {
+	var changed []string
+	for name, state := range next {
+		if prev, ok := s[name]; !ok || prev != state {
+			changed = append(changed, name)
+		}
+	}
+	for name := range s {
+		if _, ok := next[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// folders are the page folders of the flow relative to the template root, sorted
+func folders(pages []*linker.Page) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, page := range pages {
+		if name := page.Component.Name; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// layout sums up where the pages of the flow are rendered to
+func layout(pages []*linker.Page) string {
+	var paths []string
+	for _, page := range pages {
+		paths = append(paths, page.Path())
+	}
+	return strings.Join(paths, "\n")
+}
+
+// snapshot reads the state of the flow, the tracking profile, the schema definitions and
+// every file of the page folders. The files the linker writes (pages, backups, headers) and
+// hidden files (temp files, the build manifest) are left out so writing the pages does not trigger a rebuild
+func snapshot(c *config, pages []*linker.Page) sources {
+	s := sources{}
+	for _, name := range []string{c.flow, c.tracking} {
+		if name == "" {
+			continue
+		}
+		if info, err := os.Stat(name); err == nil {
+			s[name] = fileState{info.Size(), info.ModTime()}
+		}
+	}
+	if c.schemas != "" {
+		s.walk(c.schemas, nil)
+	}
+	outputs := map[string]bool{}
+	for _, page := range pages {
+		for _, name := range page.Outputs() {
+			outputs[filepath.Join(c.templates, page.Component.Name, name)] = true
+		}
+	}
+	for _, name := range folders(pages) {
+		s.walk(filepath.Join(c.templates, name), outputs)
+	}
+	return s
+}
+
+func (s sources) walk(dir string, skip map[string]bool) {
+	filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			// a folder that is missing now is picked up once it appears
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && name != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !skip[name] {
+			s[name] = fileState{info.Size(), info.ModTime()}
+		}
+		return nil
+	})
+}